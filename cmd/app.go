@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/yusufbulac/location-routing-service/internal/cache"
+	"github.com/yusufbulac/location-routing-service/internal/config"
+	"github.com/yusufbulac/location-routing-service/internal/migrations"
+	"github.com/yusufbulac/location-routing-service/internal/server"
+	"github.com/yusufbulac/location-routing-service/internal/service"
+	"gorm.io/gorm"
+)
+
+// app holds the connections shared by the server and the data commands.
+type app struct {
+	cfg   *config.Config
+	db    *gorm.DB
+	cache cache.Cache
+}
+
+// openApp connects to the database and the route cache. It refuses to use a
+// database with pending migrations.
+func openApp(cfg *config.Config) (*app, error) {
+	db, err := config.ConnectDatabase(cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	a := &app{cfg: cfg, db: db}
+
+	migrator, err := migrations.New(db)
+	if err == nil {
+		err = checkSchema(context.Background(), migrator)
+	}
+	if err != nil {
+		a.Close()
+		return nil, err
+	}
+
+	a.cache, err = cache.New(cfg.Cache)
+	if err != nil {
+		a.Close()
+		return nil, fmt.Errorf("failed to initialize cache: %w", err)
+	}
+	return a, nil
+}
+
+// locationService wires the location service as the server does, but without
+// the spatial index: a one-shot command runs too few queries to pay for loading
+// every location. Running servers notice the command's writes through the data
+// version and reload their indexes, so they need no restart.
+func (a *app) locationService(ctx context.Context) (service.LocationService, error) {
+	cfg := *a.cfg
+	cfg.SpatialIndexEnabled = false
+	return server.NewLocationService(ctx, &cfg, a.db, a.cache)
+}
+
+// Close releases the cache and the database connection.
+func (a *app) Close() {
+	if a.cache != nil {
+		if err := a.cache.Close(); err != nil {
+			log.Printf("Error closing cache: %v", err)
+		}
+	}
+
+	sqlDB, err := a.db.DB()
+	if err == nil {
+		if cerr := sqlDB.Close(); cerr != nil {
+			log.Printf("Error closing DB connection: %v", cerr)
+		} else {
+			log.Println("Database connection closed")
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/yusufbulac/location-routing-service/internal/cache"
+	"github.com/yusufbulac/location-routing-service/internal/config"
+)
+
+// runCache implements "cache flush", which makes every cached route
+// unreachable by bumping the dataset generation, as a location write does.
+func runCache(ctx context.Context, args []string, out io.Writer) error {
+	cfg, rest, err := config.LoadCommand("cache", args, nil)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 || rest[0] != "flush" {
+		return errors.New("usage: cache [flags] flush")
+	}
+
+	if cfg.Cache.Driver == cache.DriverMemory {
+		fmt.Fprintln(out, "the memory cache lives inside each server process; restart the server to clear it")
+		return nil
+	}
+
+	routeCache, err := cache.New(cfg.Cache)
+	if err != nil {
+		return err
+	}
+	defer routeCache.Close()
+
+	if err := cache.BumpGeneration(ctx, routeCache); err != nil {
+		return fmt.Errorf("flush route cache: %w", err)
+	}
+	fmt.Fprintln(out, "route cache flushed")
+	return nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yusufbulac/location-routing-service/internal/config"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+	"github.com/yusufbulac/location-routing-service/internal/server"
+	"github.com/yusufbulac/location-routing-service/internal/service"
+)
+
+// sqliteFlags points a command at a fresh SQLite database without Redis.
+func sqliteFlags(t *testing.T, name string) []string {
+	return []string{"-db-driver", "sqlite", "-sqlite-path", filepath.Join(t.TempDir(), name), "-cache-driver", "memory"}
+}
+
+// run executes the named command and returns what it printed.
+func run(t *testing.T, name string, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	err := commands[name].run(context.Background(), args, &out)
+	return out.String(), err
+}
+
+func TestCommands_RefuseUnmigratedDatabase(t *testing.T) {
+	_, err := run(t, "seed", sqliteFlags(t, "empty.db")...)
+	assert.ErrorContains(t, err, "pending migrations")
+}
+
+func TestCommands_SeedExportImportRoute(t *testing.T) {
+	source := sqliteFlags(t, "source.db")
+	_, err := run(t, "migrate", append(source, "up")...)
+	require.NoError(t, err)
+
+	out, err := run(t, "seed", source...)
+	require.NoError(t, err)
+	assert.Equal(t, "seeded 8 locations\n", out)
+
+	_, err = run(t, "seed", source...)
+	assert.ErrorContains(t, err, "already has locations")
+
+	exported := filepath.Join(t.TempDir(), "locations.json")
+	_, err = run(t, "export", append(source, "-o", exported)...)
+	require.NoError(t, err)
+
+	target := sqliteFlags(t, "target.db")
+	_, err = run(t, "migrate", append(target, "up")...)
+	require.NoError(t, err)
+	out, err = run(t, "import", append(target, exported)...)
+	require.NoError(t, err)
+	assert.Equal(t, "imported 8 locations\n", out)
+
+	out, err = run(t, "export", target...)
+	require.NoError(t, err)
+	var imported []model.Location
+	require.NoError(t, json.Unmarshal([]byte(out), &imported))
+	require.Len(t, imported, len(sampleLocations))
+	assert.Equal(t, "Istanbul", imported[0].Name)
+
+	out, err = run(t, "route", append(target, "-unit", "mi", "41", "29")...)
+	require.NoError(t, err)
+	assert.Contains(t, out, "8 stops")
+	assert.Contains(t, out, "LEG (mi)")
+
+	out, err = run(t, "route", append(target, "-start", strconv.Itoa(int(imported[0].ID)), "-return")...)
+	require.NoError(t, err)
+	assert.Contains(t, out, "8 stops", "the closing stop back at the start counts")
+	lines := strings.Split(out, "\n")
+	require.Greater(t, len(lines), 9)
+	assert.Equal(t, []string{"0", "Istanbul", "41.0082", "28.9784"}, strings.Fields(lines[1]), "the start is listed first")
+	assert.Equal(t, "Istanbul", strings.Fields(lines[9])[1], "the tour ends back at the start")
+}
+
+func TestCommands_WritesReachARunningServer(t *testing.T) {
+	ctx := context.Background()
+	flags := sqliteFlags(t, "shared.db")
+	_, err := run(t, "migrate", append(flags, "up")...)
+	require.NoError(t, err)
+
+	cfg, _, err := config.LoadCommand("serve", append(flags, "-spatial-index"), nil)
+	require.NoError(t, err)
+	a, err := openApp(cfg)
+	require.NoError(t, err)
+	defer a.Close()
+	svc, err := server.NewLocationService(ctx, cfg, a.db, a.cache)
+	require.NoError(t, err)
+
+	route, err := svc.GetRouteFrom(ctx, 41, 29)
+	require.NoError(t, err)
+	assert.Empty(t, route.Stops)
+
+	_, err = run(t, "seed", flags...)
+	require.NoError(t, err)
+
+	nearest, err := svc.GetNearestLocations(ctx, 41, 29, 1, "")
+	require.NoError(t, err)
+	require.Len(t, nearest, 1, "the server's spatial index picks up the seeded locations")
+	assert.Equal(t, "Istanbul", nearest[0].Name)
+	route, err = svc.GetRouteFrom(ctx, 41, 29)
+	require.NoError(t, err)
+	assert.Len(t, route.Stops, len(sampleLocations), "the cached empty route is not served after the seed")
+
+	file := filepath.Join(t.TempDir(), "locations.json")
+	require.NoError(t, os.WriteFile(file, []byte(`[{"name": "Edirne", "latitude": 41.6771, "longitude": 26.5557, "color": "#911eb4"}]`), 0o600))
+	_, err = run(t, "import", append(flags, file)...)
+	require.NoError(t, err)
+
+	route, err = svc.GetRouteFrom(ctx, 41, 29)
+	require.NoError(t, err)
+	require.Len(t, route.Stops, len(sampleLocations)+1)
+	var stops []string
+	for _, stop := range route.Stops {
+		stops = append(stops, stop.Location.Name)
+	}
+	assert.Contains(t, stops, "Edirne", "the imported location is routed")
+}
+
+func TestExport_ReportsFailedWrites(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full is not available")
+	}
+	flags := sqliteFlags(t, "full.db")
+	_, err := run(t, "migrate", append(flags, "up")...)
+	require.NoError(t, err)
+	_, err = run(t, "seed", flags...)
+	require.NoError(t, err)
+
+	_, err = run(t, "export", append(flags, "-o", "/dev/full")...)
+	assert.Error(t, err, "a full disk fails the export")
+}
+
+func TestImport_ReportsEveryInvalidItem(t *testing.T) {
+	flags := sqliteFlags(t, "import.db")
+	_, err := run(t, "migrate", append(flags, "up")...)
+	require.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "invalid.json")
+	require.NoError(t, os.WriteFile(file, []byte(`[
+		{"name": "Valid", "latitude": 1, "longitude": 2, "color": "#ffffff"},
+		{"name": "North", "latitude": 91, "longitude": 2, "color": "#ffffff"},
+		{"name": "", "latitude": 1, "longitude": 2, "color": "white"}
+	]`), 0o600))
+
+	_, err = run(t, "import", append(flags, file)...)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "item 1: Latitude: lte")
+	assert.Contains(t, err.Error(), "item 2: Name: required")
+
+	out, err := run(t, "export", flags...)
+	require.NoError(t, err)
+	assert.Equal(t, "[]\n", out, "nothing is imported from an invalid file")
+}
+
+func TestRoute_RejectsInvalidStart(t *testing.T) {
+	_, err := run(t, "route", append(sqliteFlags(t, "route.db"), "91", "0")...)
+	assert.ErrorContains(t, err, `invalid latitude "91"`)
+
+	_, err = run(t, "route", sqliteFlags(t, "route.db")...)
+	assert.ErrorContains(t, err, "usage: route")
+
+	_, err = run(t, "route", append(sqliteFlags(t, "route.db"), "-return", "41", "29")...)
+	assert.ErrorIs(t, err, service.ErrInvalidRouteOptions)
+}
@@ -2,100 +2,84 @@ package main
 
 import (
 	"context"
-	"github.com/gin-gonic/gin"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
+	"errors"
+	"flag"
+	"fmt"
 	_ "github.com/yusufbulac/location-routing-service/docs"
-	"github.com/yusufbulac/location-routing-service/internal/cache"
-	"github.com/yusufbulac/location-routing-service/internal/config"
-	"github.com/yusufbulac/location-routing-service/internal/handler"
 	"github.com/yusufbulac/location-routing-service/internal/logger"
-	"github.com/yusufbulac/location-routing-service/internal/middleware"
-	"github.com/yusufbulac/location-routing-service/internal/repository"
-	"github.com/yusufbulac/location-routing-service/internal/service"
+	"io"
 	"log"
-	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"strings"
 	"syscall"
-	"time"
 )
 
+// command is a subcommand of the service binary. Every command accepts the
+// configuration flags of the server in addition to its own.
+type command struct {
+	usage   string
+	summary string
+	run     func(ctx context.Context, args []string, out io.Writer) error
+}
+
+var commands = map[string]command{
+	"serve":   {"serve [flags]", "run the HTTP server (the default command)", runServe},
+	"migrate": {"migrate [flags] up | down | status | to <version>", "apply or roll back schema migrations", runMigrate},
+	"seed":    {"seed [flags]", "insert sample locations into an empty database", runSeed},
+	"import":  {"import [flags] <file>", "create locations from a JSON array (- reads stdin)", runImport},
+	"export":  {"export [flags]", "write all locations as a JSON array", runExport},
+	"route":   {"route [flags] <lat> <lng> | route -start <id> [flags]", "compute a route over the stored locations and print it", runRoute},
+	"cache":   {"cache [flags] flush", "invalidate every cached route", runCache},
+}
+
 // @title Location Routing Service API
 // @version 1.0
 // @description API for managing and routing locations.
 // @host localhost:8080
 // @BasePath /api/v1
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+// @description Admin bearer token, sent as "Bearer <ADMIN_TOKEN>"
 func main() {
 	logger.InitLogger()
 	defer logger.Log.Sync()
 
-	cache.InitRedis()
-	config.ConnectDatabase()
-
-	r := gin.Default()
-	r.Use(gin.Recovery())
-
-	// middlewares
-	r.Use(middleware.ZapLogger())
-	r.Use(middleware.RateLimitMiddleware())
-
-	// dependencies
-	locationRepo := repository.NewLocationRepository(config.DB)
-	locationService := service.NewLocationService(locationRepo)
-	locationHandler := handler.NewLocationHandler(locationService)
-
-	// Routes
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
-
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-
-	api := r.Group("/api/v1")
-	{
-		api.POST("/locations", locationHandler.CreateLocation)
-		api.GET("/locations", locationHandler.GetAllLocations)
-		api.GET("/locations/:id", locationHandler.GetLocationByID)
-		api.PUT("/locations/:id", locationHandler.UpdateLocation)
-		api.GET("/route", locationHandler.GetRoute)
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
-
-	// graceful shutdown setup
-	srv := &http.Server{
-		Addr:    ":8080",
-		Handler: r,
+	if name == "help" {
+		printUsage(os.Stdout)
+		return
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		printUsage(os.Stderr)
+		os.Exit(2)
 	}
 
-	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v\n", err)
-		}
-	}()
-
-	// Graceful shutdown
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	log.Println("Gracefully shutting down server...")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	// Shut down HTTP server
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+	if err := cmd.run(ctx, args, os.Stdout); err != nil && !errors.Is(err, flag.ErrHelp) {
+		log.Fatalf("%s: %v", name, err)
 	}
+}
 
-	// Close DB connection
-	sqlDB, err := config.DB.DB()
-	if err == nil {
-		if cerr := sqlDB.Close(); cerr != nil {
-			log.Printf("Error closing DB connection: %v", cerr)
-		} else {
-			log.Println("Database connection closed")
-		}
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	log.Println("Server exited")
+	fmt.Fprintf(w, "usage: %s <command> [flags] [arguments]\n\ncommands:\n", os.Args[0])
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-52s %s\n", commands[name].usage, commands[name].summary)
+	}
+	fmt.Fprintf(w, "\nRun '%s <command> -h' for the flags of a command.\n", os.Args[0])
 }
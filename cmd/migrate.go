@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/yusufbulac/location-routing-service/internal/config"
+	"github.com/yusufbulac/location-routing-service/internal/migrations"
+)
+
+const migrateUsage = "usage: migrate [flags] up | down | status | to <version>"
+
+// runMigrate implements the migrate subcommand. Flags are the server's
+// configuration flags; the database settings select the database to migrate.
+func runMigrate(ctx context.Context, args []string, out io.Writer) error {
+	cfg, rest, err := config.LoadCommand("migrate", args, nil)
+	if err != nil {
+		return err
+	}
+	command, target, err := parseMigrateArgs(rest)
+	if err != nil {
+		return err
+	}
+
+	db, err := config.ConnectDatabase(cfg.Database)
+	if err != nil {
+		return err
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		printMigrations(out, "applied", applied)
+		return err
+	case "down":
+		rolledBack, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if rolledBack == nil {
+			fmt.Fprintln(out, "no migrations to roll back")
+			return nil
+		}
+		printMigrations(out, "rolled back", []migrations.Migration{*rolledBack})
+		return nil
+	case "to":
+		ran, err := migrator.To(ctx, target)
+		if len(ran) == 0 {
+			printMigrations(out, "", nil)
+		}
+		for _, mig := range ran {
+			verb := "applied"
+			if mig.Version > target {
+				verb = "rolled back"
+			}
+			printMigrations(out, verb, []migrations.Migration{mig})
+		}
+		return err
+	default:
+		return printStatus(ctx, out, migrator)
+	}
+}
+
+// parseMigrateArgs validates the subcommand arguments before any database
+// connection is made. target is only set for "to".
+func parseMigrateArgs(args []string) (command string, target int64, err error) {
+	switch {
+	case len(args) == 1 && (args[0] == "up" || args[0] == "down" || args[0] == "status"):
+		return args[0], 0, nil
+	case len(args) == 2 && args[0] == "to":
+		target, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || target < 0 {
+			return "", 0, fmt.Errorf("invalid version %q", args[1])
+		}
+		return "to", target, nil
+	default:
+		return "", 0, errors.New(migrateUsage)
+	}
+}
+
+func printMigrations(out io.Writer, verb string, ran []migrations.Migration) {
+	if len(ran) == 0 {
+		fmt.Fprintln(out, "schema is up to date")
+		return
+	}
+	for _, mig := range ran {
+		fmt.Fprintf(out, "%s %04d_%s\n", verb, mig.Version, mig.Name)
+	}
+}
+
+func printStatus(ctx context.Context, out io.Writer, migrator *migrations.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, s := range statuses {
+		appliedAt := "pending"
+		if s.Applied() {
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+	}
+	return w.Flush()
+}
+
+// checkSchema refuses to serve a database with pending migrations.
+func checkSchema(ctx context.Context, migrator *migrations.Migrator) error {
+	if err := migrator.CheckCurrent(ctx); err != nil {
+		return fmt.Errorf("%w; run `%s migrate up` first", err, os.Args[0])
+	}
+	return nil
+}
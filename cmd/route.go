@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/yusufbulac/location-routing-service/internal/config"
+	"github.com/yusufbulac/location-routing-service/internal/dto"
+	"github.com/yusufbulac/location-routing-service/internal/service"
+)
+
+// runRoute computes the route from a starting point or start location over
+// the stored locations, as GET /api/v1/route does, and prints it.
+func runRoute(ctx context.Context, args []string, out io.Writer) error {
+	var (
+		unitName string
+		asJSON   bool
+		opts     service.RouteOptions
+	)
+	cfg, rest, err := config.LoadCommand("route", args, func(fs *flag.FlagSet) {
+		fs.StringVar(&unitName, "unit", "km", "distance unit: km, mi or nmi")
+		fs.BoolVar(&asJSON, "json", false, "print the API response instead of a table")
+		fs.UintVar(&opts.StartID, "start", 0, "ID of the location to start at instead of <lat> <lng>")
+		fs.UintVar(&opts.EndID, "end", 0, "ID of the location to finish at")
+		fs.BoolVar(&opts.ReturnToStart, "return", false, "finish back at the -start location")
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.StartID == 0 {
+		if opts.Lat, opts.Lng, err = parseStart(rest); err != nil {
+			return err
+		}
+	} else if len(rest) > 0 {
+		return errors.New("usage: route [flags] <lat> <lng> | route -start <id> [flags]")
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	unit, err := dto.ParseDistanceUnit(unitName)
+	if err != nil {
+		return err
+	}
+
+	a, err := openApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	svc, err := a.locationService(ctx)
+	if err != nil {
+		return err
+	}
+	route, err := svc.GetRoute(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	response := dto.NewRouteResponse(route, unit)
+	if asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(response)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "#\tNAME\tLATITUDE\tLONGITUDE\tLEG (%[1]s)\tTOTAL (%[1]s)\tBEARING\t\n", unit)
+	if start := response.Start; start != nil {
+		fmt.Fprintf(w, "0\t%s\t%.4f\t%.4f\t\t\t\t\n", start.Name, start.Latitude, start.Longitude)
+	}
+	for _, stop := range response.Stops {
+		fmt.Fprintf(w, "%d\t%s\t%.4f\t%.4f\t%.2f\t%.2f\t%.0f°\t\n",
+			stop.Sequence, stop.Location.Name, stop.Location.Latitude, stop.Location.Longitude,
+			stop.LegDistance, stop.CumulativeDistance, stop.Bearing)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%d stops, %.2f %s\n", response.Summary.StopCount, response.Summary.TotalDistance, unit)
+	return nil
+}
+
+func parseStart(args []string) (lat, lng float64, err error) {
+	if len(args) != 2 {
+		return 0, 0, errors.New("usage: route [flags] <lat> <lng> | route -start <id> [flags]")
+	}
+	lat, err = strconv.ParseFloat(args[0], 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, fmt.Errorf("invalid latitude %q", args[0])
+	}
+	lng, err = strconv.ParseFloat(args[1], 64)
+	if err != nil || lng < -180 || lng > 180 {
+		return 0, 0, fmt.Errorf("invalid longitude %q", args[1])
+	}
+	return lat, lng, nil
+}
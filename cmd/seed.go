@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/yusufbulac/location-routing-service/internal/config"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+)
+
+// sampleLocations is the demo dataset inserted by the seed command.
+var sampleLocations = []model.Location{
+	{Name: "Istanbul", Latitude: 41.0082, Longitude: 28.9784, Color: "#e6194b"},
+	{Name: "Ankara", Latitude: 39.9334, Longitude: 32.8597, Color: "#3cb44b"},
+	{Name: "Izmir", Latitude: 38.4237, Longitude: 27.1428, Color: "#4363d8"},
+	{Name: "Bursa", Latitude: 40.1885, Longitude: 29.0610, Color: "#e6194b"},
+	{Name: "Antalya", Latitude: 36.8969, Longitude: 30.7133, Color: "#f58231"},
+	{Name: "Konya", Latitude: 37.8746, Longitude: 32.4932, Color: "#3cb44b"},
+	{Name: "Trabzon", Latitude: 41.0027, Longitude: 39.7168, Color: "#4363d8"},
+	{Name: "Gaziantep", Latitude: 37.0662, Longitude: 37.3833, Color: "#f58231"},
+}
+
+func runSeed(ctx context.Context, args []string, out io.Writer) error {
+	var force bool
+	cfg, rest, err := config.LoadCommand("seed", args, func(fs *flag.FlagSet) {
+		fs.BoolVar(&force, "force", false, "seed even if the database already has locations")
+	})
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return fmt.Errorf("unexpected arguments: %v", rest)
+	}
+
+	a, err := openApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	svc, err := a.locationService(ctx)
+	if err != nil {
+		return err
+	}
+
+	existing, err := svc.GetPaginatedLocations(ctx, 1, 0)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 && !force {
+		return errors.New("database already has locations; use -force to seed anyway")
+	}
+
+	// one batch keeps the seed atomic and invalidates cached routes once
+	if err := svc.CreateLocations(ctx, slices.Clone(sampleLocations)); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "seeded %d locations\n", len(sampleLocations))
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/yusufbulac/location-routing-service/internal/config"
+	"github.com/yusufbulac/location-routing-service/internal/server"
+)
+
+// runServe serves the API until ctx is cancelled, then shuts down gracefully.
+func runServe(ctx context.Context, args []string, _ io.Writer) error {
+	cfg, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+
+	a, err := openApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	r, err := server.NewRouter(ctx, cfg, a.db, a.cache)
+	if err != nil {
+		return fmt.Errorf("failed to initialize server: %w", err)
+	}
+
+	// cancelling baseCtx aborts the work of requests still running when the
+	// shutdown timeout expires
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	srv := &http.Server{
+		Addr:    cfg.Server.Addr,
+		Handler: r,
+		BaseContext: func(net.Listener) context.Context {
+			return baseCtx
+		},
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Failed to start server: %v\n", err)
+		}
+	}()
+
+	// Graceful shutdown
+	<-ctx.Done()
+	log.Println("Gracefully shutting down server...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		cancelRequests()
+		return fmt.Errorf("server forced to shutdown: %w", err)
+	}
+
+	log.Println("Server exited")
+	return nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/yusufbulac/location-routing-service/internal/config"
+	"github.com/yusufbulac/location-routing-service/internal/dto"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+	"github.com/yusufbulac/location-routing-service/internal/validation"
+)
+
+// runImport creates the locations of a JSON array of location requests in a
+// single transaction. The whole file is validated before anything is written.
+func runImport(ctx context.Context, args []string, out io.Writer) error {
+	cfg, rest, err := config.LoadCommand("import", args, nil)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return errors.New("usage: import [flags] <file>")
+	}
+
+	locations, err := readLocations(rest[0])
+	if err != nil {
+		return err
+	}
+
+	a, err := openApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	svc, err := a.locationService(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := svc.CreateLocations(ctx, locations); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "imported %d locations\n", len(locations))
+	return nil
+}
+
+// readLocations decodes and validates the location requests in path, or in
+// stdin for "-". Every invalid item is reported by its index.
+func readLocations(path string) ([]model.Location, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var requests []dto.LocationRequest
+	if err := json.NewDecoder(r).Decode(&requests); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+
+	var errs []error
+	locations := make([]model.Location, 0, len(requests))
+	for i, req := range requests {
+		if err := validation.Validator.Struct(req); err != nil {
+			errs = append(errs, fmt.Errorf("item %d: %s", i, validation.FormatValidationError(err)))
+			continue
+		}
+		locations = append(locations, model.Location{
+			Name:      req.Name,
+			Latitude:  req.Latitude,
+			Longitude: req.Longitude,
+			Color:     req.Color,
+		})
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return locations, nil
+}
+
+// runExport writes every live location as a JSON array.
+func runExport(ctx context.Context, args []string, out io.Writer) error {
+	var output string
+	cfg, rest, err := config.LoadCommand("export", args, func(fs *flag.FlagSet) {
+		fs.StringVar(&output, "o", "", "write to this file instead of stdout")
+	})
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return fmt.Errorf("unexpected arguments: %v", rest)
+	}
+
+	a, err := openApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	svc, err := a.locationService(ctx)
+	if err != nil {
+		return err
+	}
+	locations, err := svc.GetAllLocations(ctx)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		return writeLocations(out, locations)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	// a failed close can lose buffered data, so it fails the export too
+	return errors.Join(writeLocations(f, locations), f.Close())
+}
+
+func writeLocations(w io.Writer, locations []model.Location) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(locations)
+}
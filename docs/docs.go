@@ -15,10 +15,126 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/v1/admin/locations/deleted": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Removes locations that were soft-deleted longer ago than older_than, which defaults to the configured retention and may not be shorter than it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Permanently remove soft-deleted locations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Minimum age of deleted rows, e.g. 720h",
+                        "name": "older_than",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PurgeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/locations/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Restore a deleted location",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Location ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Location"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/locations": {
             "get": {
+                "description": "Returns a GeoJSON FeatureCollection of Points when the request accepts application/geo+json",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/geo+json"
                 ],
                 "tags": [
                     "locations"
@@ -36,6 +152,12 @@ const docTemplate = `{
                         "description": "Offset",
                         "name": "offset",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Viewport filter: minLng,minLat,maxLng,maxLat (minLng \u003e maxLng crosses the antimeridian)",
+                        "name": "bbox",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -101,48 +223,93 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/locations/{id}": {
-            "get": {
+        "/api/v1/locations/batch": {
+            "post": {
+                "description": "Validates every item and stores the valid ones in a single transaction. In atomic mode (the default) nothing is stored if any item is invalid; in best_effort mode the valid items are stored. Invalid items are reported by their index.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "locations"
                 ],
-                "summary": "Get location by ID",
+                "summary": "Add several locations at once",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Location ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Locations and mode (atomic or best_effort)",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.BatchLocationRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/model.Location"
+                            "$ref": "#/definitions/dto.BatchLocationResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.BatchLocationResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
+                    }
+                }
+            }
+        },
+        "/api/v1/locations/export.csv": {
+            "get": {
+                "description": "Streams every location as CSV (id, name, latitude, longitude, color, created_at, updated_at), reading the table in batches. The X-Export-Status trailer is \"complete\" when every location was written and \"truncated\" when the export failed after the response started.",
+                "produces": [
+                    "text/csv"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Export locations as CSV",
+                "responses": {
+                    "200": {
+                        "description": "CSV file",
+                        "schema": {
+                            "type": "string"
+                        },
+                        "headers": {
+                            "X-Export-Status": {
+                                "type": "string",
+                                "description": "complete or truncated, sent as a trailer"
+                            }
+                        }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/api/v1/locations/import": {
+            "post": {
+                "description": "Reads a CSV file with a header row, a GeoJSON FeatureCollection of Points, a GPX file of waypoints or route points, or the Point placemarks of a KML file, either as the request body (by Content-Type) or as the \"file\" field of a multipart form (by file extension); format overrides both. CSV columns are found by their header names (name, latitude/lat, longitude/lng/lon, color); columns[field]=header maps a field to another header. GeoJSON features take name and color from their properties, KML placemarks their color from the icon style. color sets the color of records without one, such as GPX waypoints. Records are validated as they are read and the valid ones stored in a single transaction. In atomic mode (the default) nothing is stored if any record is invalid; in best_effort mode the valid records are stored. dry_run=true only validates.",
                 "consumes": [
-                    "application/json"
+                    "text/csv",
+                    "application/geo+json",
+                    "application/gpx+xml",
+                    "application/vnd.google-earth.kml+xml",
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
@@ -150,34 +317,80 @@ const docTemplate = `{
                 "tags": [
                     "locations"
                 ],
-                "summary": "Update an existing location",
+                "summary": "Import locations from CSV, GeoJSON, GPX or KML",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Location ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "string",
+                        "default": "atomic",
+                        "description": "atomic or best_effort",
+                        "name": "mode",
+                        "in": "query"
                     },
                     {
-                        "description": "Location JSON",
-                        "name": "location",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.LocationRequest"
-                        }
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Validate without storing",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "csv, geojson, gpx or kml",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Color (#rrggbb) of records without one",
+                        "name": "color",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Header of the CSV name column",
+                        "name": "columns[name]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Header of the CSV latitude column",
+                        "name": "columns[latitude]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Header of the CSV longitude column",
+                        "name": "columns[longitude]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Header of the CSV color column",
+                        "name": "columns[color]",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Dry run",
                         "schema": {
-                            "$ref": "#/definitions/model.Location"
+                            "$ref": "#/definitions/dto.ImportResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ImportResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ImportResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
@@ -191,29 +404,50 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/route": {
+        "/api/v1/locations/nearby": {
             "get": {
+                "description": "Returns locations within radius kilometres (at most 500) of the given point, ordered by distance",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "locations"
                 ],
-                "summary": "Get route starting from closest location",
+                "summary": "List locations within a radius",
                 "parameters": [
                     {
                         "type": "number",
-                        "description": "Reference latitude",
+                        "description": "Latitude",
                         "name": "lat",
                         "in": "query",
                         "required": true
                     },
                     {
                         "type": "number",
-                        "description": "Reference longitude",
+                        "description": "Longitude",
                         "name": "lng",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "maximum": 500,
+                        "type": "number",
+                        "description": "Radius in kilometres",
+                        "name": "radius",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -222,7 +456,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/model.Location"
+                                "$ref": "#/definitions/model.NearbyLocation"
                             }
                         }
                     },
@@ -231,59 +465,888 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "details": {
-                    "type": "string"
-                },
-                "message": {
-                    "type": "string"
-                }
-            }
         },
-        "dto.LocationRequest": {
-            "type": "object",
-            "required": [
-                "color",
-                "latitude",
-                "longitude",
-                "name"
-            ],
-            "properties": {
-                "color": {
-                    "type": "string"
-                },
-                "latitude": {
-                    "type": "number",
-                    "maximum": 90,
-                    "minimum": -90
-                },
-                "longitude": {
-                    "type": "number",
-                    "maximum": 180,
-                    "minimum": -180
-                },
-                "name": {
-                    "type": "string"
-                }
-            }
-        },
-        "model.Location": {
-            "type": "object",
-            "properties": {
-                "color": {
-                    "type": "string"
+        "/api/v1/locations/nearest": {
+            "get": {
+                "description": "Returns the k locations closest to the given point, optionally filtered by marker color",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Find the k nearest locations",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Latitude",
+                        "name": "lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude",
+                        "name": "lng",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 5,
+                        "description": "Number of locations (1-100)",
+                        "name": "k",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Marker color, e.g. #ff0000",
+                        "name": "color",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.NearbyLocation"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/locations/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Get location by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Location ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Location"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Update an existing location",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Location ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Location JSON",
+                        "name": "location",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.LocationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Location"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Soft-deletes a location; it is excluded from listings and routes until restored",
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Delete a location",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Location ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/matrix": {
+            "post": {
+                "description": "Returns the great-circle distance and estimated duration from every origin to every destination. Each point is a stored location (id) or a latitude/longitude pair; without destinations the matrix is square over the origins. Durations are in seconds at speed_kmh, or the server's default speed. The number of points per side and of matrix elements are limited by the server configuration.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Get the distance matrix between sets of points",
+                "parameters": [
+                    {
+                        "description": "Origins and destinations",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.MatrixRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "default": "km",
+                        "description": "Distance unit (km, mi, nmi)",
+                        "name": "unit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MatrixResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Location not found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/route": {
+            "get": {
+                "description": "Builds a visiting order using nearest-neighbour construction refined with 2-opt and Or-opt. The route starts at the reference point (lat, lng) or at the location start_id, such as a depot, which is then returned as start. end_id fixes the last stop; return_to_start (or end_id equal to start_id) makes a closed tour whose last stop is the start location. The response format is taken from format, or negotiated from the Accept header: geojson is a FeatureCollection with a LineString of the path followed by the stops as Points, gpx a GPX route with an rtept per location, and kml a Placemark per location styled with its color plus the path. GPX and KML are sent as attachments.",
+                "produces": [
+                    "application/json",
+                    "application/geo+json",
+                    "application/gpx+xml",
+                    "application/vnd.google-earth.kml+xml"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Get optimised route over all locations",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Reference latitude, unless start_id is given",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Reference longitude, unless start_id is given",
+                        "name": "lng",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the location to start at",
+                        "name": "start_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the location to finish at",
+                        "name": "end_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "Finish back at start_id",
+                        "name": "return_to_start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "km",
+                        "description": "Distance unit (km, mi, nmi)",
+                        "name": "unit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json, geojson, gpx or kml",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.RouteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Start or end location not found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/routes": {
+            "post": {
+                "description": "Builds a route like GET /api/v1/route over a subset of the stored locations: those listed in location_ids and/or having one of colors (both must match when both are given). Every listed ID must exist. The route starts at start or at the location start_id, which need not be part of the selection, and may finish at end_id or back at the start (return_to_start). Response formats are those of GET /api/v1/route.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/geo+json",
+                    "application/gpx+xml",
+                    "application/vnd.google-earth.kml+xml"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Get optimised route over selected locations",
+                "parameters": [
+                    {
+                        "description": "Locations and anchors of the route",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RouteRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "default": "km",
+                        "description": "Distance unit (km, mi, nmi)",
+                        "name": "unit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "json, geojson, gpx or kml",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.RouteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Selected, start or end location not found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Reports \"ok\", or \"degraded\" while the cache is unavailable; the service keeps serving either way",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Service health",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.HealthResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.BatchCreatedLocation": {
+            "type": "object",
+            "properties": {
+                "index": {
+                    "type": "integer"
+                },
+                "location": {
+                    "$ref": "#/definitions/model.Location"
+                }
+            }
+        },
+        "dto.BatchItemError": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.BatchLocationRequest": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/dto.LocationRequest"
+                    }
+                },
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "atomic",
+                        "best_effort"
+                    ],
+                    "example": "atomic"
+                }
+            }
+        },
+        "dto.BatchLocationResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BatchCreatedLocation"
+                    }
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BatchItemError"
+                    }
+                },
+                "mode": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CacheHealth": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "type": "boolean"
+                },
+                "circuit": {
+                    "type": "string"
+                },
+                "driver": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.DistanceUnit": {
+            "type": "string",
+            "enum": [
+                "km",
+                "mi",
+                "nmi"
+            ],
+            "x-enum-varnames": [
+                "UnitKilometers",
+                "UnitMiles",
+                "UnitNauticalMiles"
+            ]
+        },
+        "dto.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "cache": {
+                    "$ref": "#/definitions/dto.CacheHealth"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ImportResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ImportRowError"
+                    }
+                },
+                "errors_truncated": {
+                    "type": "boolean"
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "rows": {
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.ImportRowError": {
+            "type": "object",
+            "properties": {
+                "details": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "row": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.LocationRequest": {
+            "type": "object",
+            "required": [
+                "color",
+                "latitude",
+                "longitude",
+                "name"
+            ],
+            "properties": {
+                "color": {
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number",
+                    "maximum": 90,
+                    "minimum": -90
+                },
+                "longitude": {
+                    "type": "number",
+                    "maximum": 180,
+                    "minimum": -180
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MatrixPointRequest": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "latitude": {
+                    "type": "number",
+                    "maximum": 90,
+                    "minimum": -90,
+                    "example": 41.0082
+                },
+                "longitude": {
+                    "type": "number",
+                    "maximum": 180,
+                    "minimum": -180,
+                    "example": 28.9784
+                }
+            }
+        },
+        "dto.MatrixRequest": {
+            "type": "object",
+            "required": [
+                "origins"
+            ],
+            "properties": {
+                "destinations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.MatrixPointRequest"
+                    }
+                },
+                "origins": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/dto.MatrixPointRequest"
+                    }
+                },
+                "speed_kmh": {
+                    "description": "SpeedKmh is the average speed durations are estimated with; zero uses\nthe server default.",
+                    "type": "number",
+                    "maximum": 1000,
+                    "example": 50
+                }
+            }
+        },
+        "dto.MatrixResponse": {
+            "type": "object",
+            "properties": {
+                "destinations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.MatrixPoint"
+                    }
+                },
+                "distances": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "number"
+                        }
+                    }
+                },
+                "durations": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "number"
+                        }
+                    }
+                },
+                "origins": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.MatrixPoint"
+                    }
+                },
+                "speed_kmh": {
+                    "type": "number"
+                },
+                "unit": {
+                    "$ref": "#/definitions/dto.DistanceUnit"
+                }
+            }
+        },
+        "dto.PurgeResponse": {
+            "type": "object",
+            "properties": {
+                "older_than": {
+                    "type": "string"
+                },
+                "purged": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.RoutePoint": {
+            "type": "object",
+            "properties": {
+                "latitude": {
+                    "type": "number",
+                    "maximum": 90,
+                    "minimum": -90,
+                    "example": 41.0082
+                },
+                "longitude": {
+                    "type": "number",
+                    "maximum": 180,
+                    "minimum": -180,
+                    "example": 28.9784
+                }
+            }
+        },
+        "dto.RouteRequest": {
+            "type": "object",
+            "properties": {
+                "colors": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "#ff0000"
+                    ]
+                },
+                "end_id": {
+                    "type": "integer"
+                },
+                "location_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        3,
+                        8,
+                        21
+                    ]
+                },
+                "return_to_start": {
+                    "type": "boolean"
+                },
+                "start": {
+                    "$ref": "#/definitions/dto.RoutePoint"
+                },
+                "start_id": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "dto.RouteResponse": {
+            "type": "object",
+            "properties": {
+                "start": {
+                    "$ref": "#/definitions/model.Location"
+                },
+                "stops": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RouteStopResponse"
+                    }
+                },
+                "summary": {
+                    "$ref": "#/definitions/dto.RouteSummary"
+                }
+            }
+        },
+        "dto.RouteStopResponse": {
+            "type": "object",
+            "properties": {
+                "bearing": {
+                    "type": "number"
+                },
+                "cumulative_distance": {
+                    "type": "number"
+                },
+                "leg_distance": {
+                    "type": "number"
+                },
+                "location": {
+                    "$ref": "#/definitions/model.Location"
+                },
+                "sequence": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.RouteSummary": {
+            "type": "object",
+            "properties": {
+                "stop_count": {
+                    "type": "integer"
+                },
+                "total_distance": {
+                    "type": "number"
+                },
+                "unit": {
+                    "$ref": "#/definitions/dto.DistanceUnit"
+                }
+            }
+        },
+        "model.Location": {
+            "type": "object",
+            "properties": {
+                "color": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.MatrixPoint": {
+            "type": "object",
+            "properties": {
+                "latitude": {
+                    "type": "number"
+                },
+                "location_id": {
+                    "type": "integer"
+                },
+                "longitude": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.NearbyLocation": {
+            "type": "object",
+            "properties": {
+                "color": {
+                    "type": "string"
                 },
                 "created_at": {
                     "type": "string"
                 },
+                "distance_km": {
+                    "type": "number"
+                },
                 "id": {
                     "type": "integer"
                 },
@@ -301,6 +1364,14 @@ const docTemplate = `{
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "AdminToken": {
+            "description": "Admin bearer token, sent as \"Bearer \u003cADMIN_TOKEN\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrMiss is returned by Get when the key is absent or expired.
+var ErrMiss = errors.New("cache: miss")
+
+// ErrUnavailable is returned without contacting the backend while its circuit is open.
+var ErrUnavailable = errors.New("cache: unavailable")
+
+// Cache is a byte-oriented key/value store with per-entry expiry.
+type Cache interface {
+	// Get returns the value stored under key or ErrMiss.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value under key; a ttl of zero keeps it until evicted.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Incr atomically increments the integer stored under key, starting from 0.
+	Incr(ctx context.Context, key string) (int64, error)
+	// Status reports the availability of the backing store.
+	Status() Status
+	// Close releases the resources held by the cache.
+	Close() error
+}
+
+// Status describes the health of a cache.
+type Status struct {
+	Driver    string
+	Available bool
+	// Circuit is "closed" or "open" for caches guarded by a circuit breaker.
+	Circuit   string
+	LastError string
+}
+
+const (
+	DriverRedis  = "redis"
+	DriverMemory = "memory"
+)
+
+type Config struct {
+	Driver         string
+	RedisAddr      string
+	RedisPassword  string
+	MemoryCapacity int
+	// FailureThreshold is the number of consecutive Redis errors that open the circuit.
+	FailureThreshold int
+	// ReconnectInterval is how often an open circuit probes Redis.
+	ReconnectInterval time.Duration
+}
+
+// New builds the cache implementation selected by cfg.Driver.
+func New(cfg Config) (Cache, error) {
+	switch cfg.Driver {
+	case DriverRedis:
+		return NewRedisCache(cfg), nil
+	case DriverMemory:
+		return NewMemoryCache(cfg.MemoryCapacity), nil
+	default:
+		return nil, fmt.Errorf("unknown cache driver %q (expected %q or %q)", cfg.Driver, DriverRedis, DriverMemory)
+	}
+}
+
+// generationKey holds a counter bumped on every write to the locations
+// dataset. Keys derived from the dataset embed it, so a bump makes all of
+// them unreachable at once; the stale entries simply expire.
+const generationKey = "locations:generation"
+
+// counter is the part of a cache the generation helpers rely on.
+type counter interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Incr(ctx context.Context, key string) (int64, error)
+}
+
+// Generation returns the current dataset generation, 0 if none was recorded.
+func Generation(ctx context.Context, c counter) (int64, error) {
+	value, err := c.Get(ctx, generationKey)
+	if errors.Is(err, ErrMiss) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(value), 10, 64)
+}
+
+// BumpGeneration invalidates every key derived from the current generation.
+func BumpGeneration(ctx context.Context, c counter) error {
+	_, err := c.Incr(ctx, generationKey)
+	return err
+}
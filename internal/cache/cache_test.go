@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCache_GetSet(t *testing.T) {
+	c := NewMemoryCache(8)
+
+	_, err := c.Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrMiss)
+
+	require.NoError(t, c.Set(context.Background(), "key", []byte("value"), 0))
+	value, err := c.Get(context.Background(), "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+}
+
+func TestMemoryCache_Expiry(t *testing.T) {
+	c := NewMemoryCache(8).(*memoryCache)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Set(context.Background(), "key", []byte("value"), time.Minute))
+
+	now = now.Add(59 * time.Second)
+	_, err := c.Get(context.Background(), "key")
+	assert.NoError(t, err)
+
+	now = now.Add(time.Second)
+	_, err = c.Get(context.Background(), "key")
+	assert.ErrorIs(t, err, ErrMiss)
+}
+
+func TestMemoryCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewMemoryCache(2)
+
+	require.NoError(t, c.Set(context.Background(), "a", []byte("1"), 0))
+	require.NoError(t, c.Set(context.Background(), "b", []byte("2"), 0))
+	_, err := c.Get(context.Background(), "a")
+	require.NoError(t, err)
+	require.NoError(t, c.Set(context.Background(), "c", []byte("3"), 0))
+
+	_, err = c.Get(context.Background(), "b")
+	assert.ErrorIs(t, err, ErrMiss)
+	_, err = c.Get(context.Background(), "a")
+	assert.NoError(t, err)
+	_, err = c.Get(context.Background(), "c")
+	assert.NoError(t, err)
+}
+
+func TestMemoryCache_NeverEvictsCounters(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache(2)
+
+	require.NoError(t, BumpGeneration(ctx, c))
+	gen, err := Generation(ctx, c)
+	require.NoError(t, err)
+	oldKey := "route:" + strconv.FormatInt(gen, 10)
+	require.NoError(t, c.Set(ctx, oldKey, []byte("old route"), 0))
+
+	// fill the cache past its capacity, then invalidate the old route
+	for i := 0; i < 4; i++ {
+		require.NoError(t, c.Set(ctx, "filler:"+strconv.Itoa(i), []byte("x"), 0))
+	}
+	require.NoError(t, c.Set(ctx, oldKey, []byte("old route"), 0))
+	require.NoError(t, BumpGeneration(ctx, c))
+
+	gen, err = Generation(ctx, c)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), gen, "the generation survives eviction")
+	_, err = c.Get(ctx, "route:"+strconv.FormatInt(gen, 10))
+	assert.ErrorIs(t, err, ErrMiss, "the old route is not reachable under the new generation")
+}
+
+func TestGeneration(t *testing.T) {
+	server := miniredis.RunT(t)
+	redisCache := NewRedisCache(Config{RedisAddr: server.Addr()})
+	defer redisCache.Close()
+
+	for name, c := range map[string]Cache{"memory": NewMemoryCache(8), "redis": redisCache} {
+		t.Run(name, func(t *testing.T) {
+			gen, err := Generation(context.Background(), c)
+			require.NoError(t, err)
+			assert.Zero(t, gen)
+
+			require.NoError(t, BumpGeneration(context.Background(), c))
+			require.NoError(t, BumpGeneration(context.Background(), c))
+
+			gen, err = Generation(context.Background(), c)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), gen)
+		})
+	}
+}
+
+func TestRedisCache_GetSet(t *testing.T) {
+	server := miniredis.RunT(t)
+	c := NewRedisCache(Config{RedisAddr: server.Addr()})
+	defer c.Close()
+
+	_, err := c.Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrMiss)
+
+	require.NoError(t, c.Set(context.Background(), "key", []byte("value"), time.Minute))
+	value, err := c.Get(context.Background(), "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+
+	server.FastForward(time.Minute)
+	_, err = c.Get(context.Background(), "key")
+	assert.ErrorIs(t, err, ErrMiss)
+}
+
+func TestNew(t *testing.T) {
+	c, err := New(Config{Driver: DriverMemory})
+	require.NoError(t, err)
+	assert.IsType(t, &memoryCache{}, c)
+	assert.Equal(t, Status{Driver: DriverMemory, Available: true}, c.Status())
+
+	_, err = New(Config{Driver: "memcached"})
+	assert.Error(t, err)
+}
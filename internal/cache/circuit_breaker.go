@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultFailureThreshold  = 3
+	DefaultReconnectInterval = 5 * time.Second
+
+	pingTimeout = time.Second
+)
+
+// backend is a remote store wrapped by the circuit breaker.
+type backend interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Incr(ctx context.Context, key string) (int64, error)
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// circuitBreaker stops calling a failing backend after threshold consecutive
+// errors and fails fast with ErrUnavailable while open. A background loop
+// pings the backend every interval and closes the circuit once it answers.
+type circuitBreaker struct {
+	backend   backend
+	driver    string
+	threshold int
+	interval  time.Duration
+
+	mu       sync.Mutex
+	open     bool
+	failures int
+	lastErr  error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newCircuitBreaker(b backend, driver string, threshold int, interval time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		threshold = DefaultFailureThreshold
+	}
+	if interval <= 0 {
+		interval = DefaultReconnectInterval
+	}
+
+	cb := &circuitBreaker{
+		backend:   b,
+		driver:    driver,
+		threshold: threshold,
+		interval:  interval,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	if err := cb.ping(); err != nil {
+		log.Printf("%s unavailable, starting with cache disabled: %v", driver, err)
+		cb.open, cb.lastErr = true, err
+	} else {
+		log.Printf("%s connection established", driver)
+	}
+
+	go cb.reconnectLoop()
+	return cb
+}
+
+func (cb *circuitBreaker) Get(ctx context.Context, key string) ([]byte, error) {
+	var value []byte
+	err := cb.call(func() error {
+		var err error
+		value, err = cb.backend.Get(ctx, key)
+		return err
+	})
+	return value, err
+}
+
+func (cb *circuitBreaker) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return cb.call(func() error {
+		return cb.backend.Set(ctx, key, value, ttl)
+	})
+}
+
+func (cb *circuitBreaker) Incr(ctx context.Context, key string) (int64, error) {
+	var n int64
+	err := cb.call(func() error {
+		var err error
+		n, err = cb.backend.Incr(ctx, key)
+		return err
+	})
+	return n, err
+}
+
+func (cb *circuitBreaker) Status() Status {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	status := Status{Driver: cb.driver, Available: !cb.open, Circuit: "closed"}
+	if cb.open {
+		status.Circuit = "open"
+	}
+	if cb.lastErr != nil {
+		status.LastError = cb.lastErr.Error()
+	}
+	return status
+}
+
+func (cb *circuitBreaker) Close() error {
+	close(cb.stop)
+	<-cb.done
+	return cb.backend.Close()
+}
+
+func (cb *circuitBreaker) call(fn func() error) error {
+	cb.mu.Lock()
+	open := cb.open
+	cb.mu.Unlock()
+	if open {
+		return ErrUnavailable
+	}
+
+	err := fn()
+	cb.record(err)
+	return err
+}
+
+// record counts consecutive backend failures; cache misses and cancelled
+// requests say nothing about the backend's health.
+func (cb *circuitBreaker) record(err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if err == nil || errors.Is(err, ErrMiss) {
+		cb.failures = 0
+		return
+	}
+
+	cb.failures++
+	cb.lastErr = err
+	if !cb.open && cb.failures >= cb.threshold {
+		cb.open = true
+		log.Printf("%s circuit opened after %d consecutive failures: %v", cb.driver, cb.failures, err)
+	}
+}
+
+func (cb *circuitBreaker) reconnectLoop() {
+	defer close(cb.done)
+
+	ticker := time.NewTicker(cb.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cb.stop:
+			return
+		case <-ticker.C:
+			cb.mu.Lock()
+			open := cb.open
+			cb.mu.Unlock()
+			if open {
+				cb.tryReconnect()
+			}
+		}
+	}
+}
+
+func (cb *circuitBreaker) tryReconnect() {
+	if err := cb.ping(); err != nil {
+		cb.mu.Lock()
+		cb.lastErr = err
+		cb.mu.Unlock()
+		return
+	}
+
+	// Writes made while the cache was unreachable could not invalidate the
+	// data cached before the outage, so discard it before serving again.
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	if err := BumpGeneration(ctx, cb.backend); err != nil {
+		cb.mu.Lock()
+		cb.lastErr = err
+		cb.mu.Unlock()
+		return
+	}
+
+	cb.mu.Lock()
+	cb.open, cb.failures, cb.lastErr = false, 0, nil
+	cb.mu.Unlock()
+	log.Printf("%s connection restored, circuit closed", cb.driver)
+}
+
+func (cb *circuitBreaker) ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	return cb.backend.Ping(ctx)
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testReconnectInterval = 20 * time.Millisecond
+
+func TestCircuitBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	server := miniredis.RunT(t)
+	c := NewRedisCache(Config{RedisAddr: server.Addr(), FailureThreshold: 2, ReconnectInterval: time.Hour})
+	defer c.Close()
+
+	require.NoError(t, c.Set(context.Background(), "key", []byte("value"), 0))
+	assert.Equal(t, "closed", c.Status().Circuit)
+
+	server.Close()
+
+	_, err := c.Get(context.Background(), "key")
+	assert.Error(t, err)
+	assert.True(t, c.Status().Available, "a single failure must not open the circuit")
+
+	_, err = c.Get(context.Background(), "key")
+	assert.Error(t, err)
+
+	status := c.Status()
+	assert.False(t, status.Available)
+	assert.Equal(t, "open", status.Circuit)
+	assert.NotEmpty(t, status.LastError)
+
+	_, err = c.Get(context.Background(), "key")
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+func TestCircuitBreaker_StartsOpenAndReconnects(t *testing.T) {
+	server := miniredis.NewMiniRedis()
+	require.NoError(t, server.Start())
+	addr := server.Addr()
+	server.Close()
+
+	c := NewRedisCache(Config{RedisAddr: addr, ReconnectInterval: testReconnectInterval})
+	defer c.Close()
+
+	assert.False(t, c.Status().Available)
+	_, err := c.Get(context.Background(), "key")
+	assert.ErrorIs(t, err, ErrUnavailable)
+
+	require.NoError(t, server.StartAddr(addr))
+	defer server.Close()
+
+	assert.Eventually(t, func() bool {
+		return c.Status().Available
+	}, time.Second, testReconnectInterval)
+
+	require.NoError(t, c.Set(context.Background(), "key", []byte("value"), 0))
+	value, err := c.Get(context.Background(), "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+}
+
+func TestCircuitBreaker_ReconnectInvalidatesGeneration(t *testing.T) {
+	server := miniredis.RunT(t)
+	c := NewRedisCache(Config{RedisAddr: server.Addr(), FailureThreshold: 1, ReconnectInterval: testReconnectInterval})
+	defer c.Close()
+
+	before, err := Generation(context.Background(), c)
+	require.NoError(t, err)
+
+	server.SetError("LOADING")
+	_, err = c.Get(context.Background(), "key")
+	require.Error(t, err)
+	require.False(t, c.Status().Available)
+	server.SetError("")
+
+	assert.Eventually(t, func() bool {
+		return c.Status().Available
+	}, time.Second, testReconnectInterval)
+
+	after, err := Generation(context.Background(), c)
+	require.NoError(t, err)
+	assert.Greater(t, after, before)
+}
@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultMemoryCapacity is the entry limit used when none is configured.
+const DefaultMemoryCapacity = 1024
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// memoryCache is an in-process LRU cache with per-entry TTL. Counters written
+// by Incr are kept outside the LRU and never expire: evicting one, such as the
+// dataset generation, would restart it and make old derived keys live again.
+type memoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+	counters map[string]int64
+	now      func() time.Time
+}
+
+// NewMemoryCache creates an in-process cache holding at most capacity entries,
+// evicting the least recently used one when full.
+func NewMemoryCache(capacity int) Cache {
+	if capacity <= 0 {
+		capacity = DefaultMemoryCapacity
+	}
+	return &memoryCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		counters: make(map[string]int64),
+		now:      time.Now,
+	}
+}
+
+func (c *memoryCache) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if n, ok := c.counters[key]; ok {
+		return []byte(strconv.FormatInt(n, 10)), nil
+	}
+	entry, ok := c.lookup(key)
+	if !ok {
+		return nil, ErrMiss
+	}
+	return append([]byte(nil), entry.value...), nil
+}
+
+func (c *memoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.counters, key)
+	c.store(key, append([]byte(nil), value...), ttl)
+	return nil
+}
+
+func (c *memoryCache) Incr(_ context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n, ok := c.counters[key]
+	if !ok {
+		// a value stored by Set becomes a counter
+		if entry, found := c.lookup(key); found {
+			parsed, err := strconv.ParseInt(string(entry.value), 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			n = parsed
+			c.order.Remove(c.entries[key])
+			delete(c.entries, key)
+		}
+	}
+	n++
+	c.counters[key] = n
+	return n, nil
+}
+
+func (c *memoryCache) Status() Status {
+	return Status{Driver: DriverMemory, Available: true}
+}
+
+func (c *memoryCache) Close() error {
+	return nil
+}
+
+// lookup returns the live entry for key, marking it most recently used.
+func (c *memoryCache) lookup(key string) (*memoryEntry, bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry, true
+}
+
+func (c *memoryCache) store(key string, value []byte, ttl time.Duration) {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = c.now().Add(ttl)
+	}
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*memoryEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryEntry).key)
+	}
+}
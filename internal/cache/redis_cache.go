@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisDialTimeout = time.Second
+	redisIOTimeout   = 500 * time.Millisecond
+)
+
+// redisBackend talks to Redis; it is always used behind a circuit breaker.
+type redisBackend struct {
+	client *redis.Client
+}
+
+// NewRedisCache returns a Redis-backed cache guarded by a circuit breaker.
+// It never fails: when Redis is unreachable the circuit starts open and is
+// closed by the background reconnection loop once Redis answers again.
+func NewRedisCache(cfg Config) Cache {
+	client := redis.NewClient(&redis.Options{
+		Addr:         cfg.RedisAddr,
+		Password:     cfg.RedisPassword,
+		DB:           0,
+		DialTimeout:  redisDialTimeout,
+		ReadTimeout:  redisIOTimeout,
+		WriteTimeout: redisIOTimeout,
+	})
+	return newCircuitBreaker(&redisBackend{client: client}, DriverRedis, cfg.FailureThreshold, cfg.ReconnectInterval)
+}
+
+func (b *redisBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := b.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return value, err
+}
+
+func (b *redisBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return b.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (b *redisBackend) Incr(ctx context.Context, key string) (int64, error) {
+	return b.client.Incr(ctx, key).Result()
+}
+
+func (b *redisBackend) Ping(ctx context.Context) error {
+	return b.client.Ping(ctx).Err()
+}
+
+func (b *redisBackend) Close() error {
+	return b.client.Close()
+}
@@ -1,26 +0,0 @@
-package cache
-
-import (
-	"context"
-	"github.com/redis/go-redis/v9"
-	"log"
-	"os"
-)
-
-var Ctx = context.Background()
-
-var Redis *redis.Client
-
-func InitRedis() {
-	Redis = redis.NewClient(&redis.Options{
-		Addr:     os.Getenv("REDIS_ADDR"),
-		Password: os.Getenv("REDIS_PASSWORD"),
-		DB:       0,
-	})
-
-	_, err := Redis.Ping(Ctx).Result()
-	if err != nil {
-		log.Fatalf("Failed to connect to Redis: %v", err)
-	}
-	log.Println("Redis connection established")
-}
@@ -0,0 +1,369 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/yusufbulac/location-routing-service/internal/cache"
+)
+
+// Config is the complete service configuration. It is read from the
+// environment (after loading .env) and may be overridden by command-line flags.
+type Config struct {
+	Server    ServerConfig
+	Database  DatabaseConfig
+	Cache     cache.Config
+	RateLimit RateLimitConfig
+	Matrix    MatrixConfig
+
+	// SpatialIndexEnabled serves spatial queries from an in-memory index. It
+	// defaults to on for SQLite only; MySQL and PostgreSQL answer spatial
+	// queries from their own spatial indexes.
+	SpatialIndexEnabled bool
+	// SoftDeleteRetention is the age after which deleted locations may be
+	// purged; purges of younger rows are refused.
+	SoftDeleteRetention time.Duration
+	// ImportMaxBytes caps the request body of an import.
+	ImportMaxBytes int64
+	// AdminToken is the bearer token of the admin endpoints, which are
+	// disabled while it is empty.
+	AdminToken string
+}
+
+type ServerConfig struct {
+	Addr string
+	// RequestTimeout bounds the work done for a single request; zero disables it.
+	RequestTimeout time.Duration
+	// ExportTimeout replaces RequestTimeout for streaming exports; zero disables it.
+	ExportTimeout time.Duration
+	// ShutdownTimeout is how long in-flight requests may run after a shutdown signal.
+	ShutdownTimeout time.Duration
+}
+
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// defaultPorts are the database ports used when DB_PORT is unset.
+var defaultPorts = map[string]int{
+	DriverMySQL:    3306,
+	DriverPostgres: 5432,
+}
+
+type DatabaseConfig struct {
+	// Driver is DriverMySQL, DriverPostgres or DriverSQLite.
+	Driver string
+	// SQLitePath is the database file used by the SQLite driver.
+	SQLitePath string
+
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Name     string
+	// SSLMode is the Postgres sslmode, e.g. disable or require.
+	SSLMode string
+}
+
+// DSN returns the data source name for the configured driver.
+func (c DatabaseConfig) DSN() string {
+	if c.Driver == DriverSQLite {
+		// WAL and a busy timeout let concurrent requests share the file
+		return fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", c.SQLitePath)
+	}
+	if c.Driver == DriverPostgres {
+		dsn := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(c.User, c.Password),
+			Host:     net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+			Path:     "/" + c.Name,
+			RawQuery: url.Values{"sslmode": {c.SSLMode}}.Encode(),
+		}
+		return dsn.String()
+	}
+	return fmt.Sprintf(
+		"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		c.User, c.Password, c.Host, c.Port, c.Name,
+	)
+}
+
+// RateLimitConfig allows Requests requests per client IP every Period.
+type RateLimitConfig struct {
+	Requests int64
+	Period   time.Duration
+}
+
+// MatrixConfig limits distance matrix requests.
+type MatrixConfig struct {
+	// MaxPoints caps the number of origins and of destinations.
+	MaxPoints int
+	// MaxElements caps origins × destinations.
+	MaxElements int
+	// SpeedKmh is the average speed durations are estimated with when a
+	// request does not give one.
+	SpeedKmh float64
+}
+
+// Load reads .env and the environment, applies the command-line flags in args
+// and validates the result. The returned error lists every invalid setting.
+func Load(args []string) (*Config, error) {
+	LoadEnv(".env")
+	return load(args, os.Getenv, os.Stderr)
+}
+
+// LoadCommand is Load for the subcommand name. flags, if not nil, registers
+// the subcommand's own flags next to the configuration flags. Arguments after
+// the flags are returned instead of being rejected.
+func LoadCommand(name string, args []string, flags func(*flag.FlagSet)) (*Config, []string, error) {
+	LoadEnv(".env")
+	return parse(name, args, os.Getenv, os.Stderr, flags)
+}
+
+func load(args []string, getenv func(string) string, output io.Writer) (*Config, error) {
+	cfg, rest, err := parse("location-routing-service", args, getenv, output, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %v", rest)
+	}
+	return cfg, nil
+}
+
+func parse(name string, args []string, getenv func(string) string, output io.Writer, flags func(*flag.FlagSet)) (*Config, []string, error) {
+	env := envReader{getenv: getenv}
+	cfg := &Config{
+		Server: ServerConfig{
+			Addr:            env.string("SERVER_ADDR", ":8080"),
+			RequestTimeout:  env.duration("REQUEST_TIMEOUT", 10*time.Second),
+			ExportTimeout:   env.duration("EXPORT_TIMEOUT", 10*time.Minute),
+			ShutdownTimeout: env.duration("SHUTDOWN_TIMEOUT", 5*time.Second),
+		},
+		Database: env.database(),
+		Cache: cache.Config{
+			Driver:            env.string("CACHE_DRIVER", cache.DriverRedis),
+			RedisAddr:         env.string("REDIS_ADDR", "localhost:6379"),
+			RedisPassword:     env.string("REDIS_PASSWORD", ""),
+			MemoryCapacity:    env.int("CACHE_MEMORY_CAPACITY", cache.DefaultMemoryCapacity),
+			FailureThreshold:  env.int("CACHE_FAILURE_THRESHOLD", cache.DefaultFailureThreshold),
+			ReconnectInterval: env.duration("CACHE_RECONNECT_INTERVAL", cache.DefaultReconnectInterval),
+		},
+		RateLimit: RateLimitConfig{
+			Requests: int64(env.int("RATE_LIMIT_REQUESTS", 10)),
+			Period:   env.duration("RATE_LIMIT_PERIOD", time.Minute),
+		},
+		Matrix: MatrixConfig{
+			MaxPoints:   env.int("MATRIX_MAX_POINTS", 1000),
+			MaxElements: env.int("MATRIX_MAX_ELEMENTS", 250000),
+			SpeedKmh:    env.float("MATRIX_SPEED_KMH", 50),
+		},
+		SpatialIndexEnabled: env.bool("SPATIAL_INDEX_ENABLED", false),
+		SoftDeleteRetention: env.duration("SOFT_DELETE_RETENTION", 30*24*time.Hour),
+		ImportMaxBytes:      int64(env.int("IMPORT_MAX_BYTES", 32<<20)),
+		AdminToken:          env.string("ADMIN_TOKEN", ""),
+	}
+
+	// flags default to the environment values, so only given flags override them
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.StringVar(&cfg.Server.Addr, "addr", cfg.Server.Addr, "HTTP listen address (SERVER_ADDR)")
+	fs.DurationVar(&cfg.Server.RequestTimeout, "request-timeout", cfg.Server.RequestTimeout, "per-request deadline, 0 to disable (REQUEST_TIMEOUT)")
+	fs.DurationVar(&cfg.Server.ExportTimeout, "export-timeout", cfg.Server.ExportTimeout, "deadline of streaming exports, 0 to disable (EXPORT_TIMEOUT)")
+	fs.DurationVar(&cfg.Server.ShutdownTimeout, "shutdown-timeout", cfg.Server.ShutdownTimeout, "graceful shutdown timeout (SHUTDOWN_TIMEOUT)")
+	fs.StringVar(&cfg.Database.Driver, "db-driver", cfg.Database.Driver, "database driver: mysql, postgres or sqlite (DB_DRIVER)")
+	fs.StringVar(&cfg.Database.SQLitePath, "sqlite-path", cfg.Database.SQLitePath, "SQLite database file (SQLITE_PATH)")
+	fs.StringVar(&cfg.Database.Host, "db-host", cfg.Database.Host, "database host (DB_HOST)")
+	fs.IntVar(&cfg.Database.Port, "db-port", cfg.Database.Port, "database port (DB_PORT)")
+	fs.StringVar(&cfg.Database.User, "db-user", cfg.Database.User, "database user (DB_USER)")
+	fs.StringVar(&cfg.Database.Name, "db-name", cfg.Database.Name, "database name (DB_NAME)")
+	fs.StringVar(&cfg.Cache.Driver, "cache-driver", cfg.Cache.Driver, "route cache driver: redis or memory (CACHE_DRIVER)")
+	fs.StringVar(&cfg.Cache.RedisAddr, "redis-addr", cfg.Cache.RedisAddr, "Redis address (REDIS_ADDR)")
+	fs.Int64Var(&cfg.RateLimit.Requests, "rate-limit", cfg.RateLimit.Requests, "requests allowed per client IP per period (RATE_LIMIT_REQUESTS)")
+	fs.DurationVar(&cfg.RateLimit.Period, "rate-limit-period", cfg.RateLimit.Period, "rate limit window (RATE_LIMIT_PERIOD)")
+	fs.IntVar(&cfg.Matrix.MaxPoints, "matrix-max-points", cfg.Matrix.MaxPoints, "most origins or destinations per distance matrix (MATRIX_MAX_POINTS)")
+	fs.IntVar(&cfg.Matrix.MaxElements, "matrix-max-elements", cfg.Matrix.MaxElements, "most origins × destinations per distance matrix (MATRIX_MAX_ELEMENTS)")
+	fs.Float64Var(&cfg.Matrix.SpeedKmh, "matrix-speed", cfg.Matrix.SpeedKmh, "default average speed in km/h for matrix durations (MATRIX_SPEED_KMH)")
+	fs.BoolVar(&cfg.SpatialIndexEnabled, "spatial-index", cfg.SpatialIndexEnabled, "serve spatial queries from an in-memory index; on by default for sqlite only (SPATIAL_INDEX_ENABLED)")
+	fs.DurationVar(&cfg.SoftDeleteRetention, "soft-delete-retention", cfg.SoftDeleteRetention, "minimum age of deleted locations to purge (SOFT_DELETE_RETENTION)")
+	fs.Int64Var(&cfg.ImportMaxBytes, "import-max-bytes", cfg.ImportMaxBytes, "largest accepted import request body in bytes (IMPORT_MAX_BYTES)")
+	fs.StringVar(&cfg.AdminToken, "admin-token", cfg.AdminToken, "bearer token of the admin endpoints, empty to disable them (ADMIN_TOKEN)")
+	if flags != nil {
+		flags(fs)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	if cfg.Database.Port == 0 {
+		cfg.Database.Port = defaultPorts[cfg.Database.Driver]
+	}
+	if getenv("SPATIAL_INDEX_ENABLED") == "" && !isSet(fs, "spatial-index") {
+		cfg.SpatialIndexEnabled = cfg.Database.Driver == DriverSQLite
+	}
+
+	if err := errors.Join(errors.Join(env.errs...), cfg.Validate()); err != nil {
+		return nil, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+// isSet reports whether the flag was given on the command line.
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
+// Validate reports every invalid setting, naming the environment variable
+// that controls it.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Addr != "", "SERVER_ADDR must not be empty")
+	check(c.Server.RequestTimeout >= 0, "REQUEST_TIMEOUT must not be negative, got %s", c.Server.RequestTimeout)
+	check(c.Server.ExportTimeout >= 0, "EXPORT_TIMEOUT must not be negative, got %s", c.Server.ExportTimeout)
+	check(c.Server.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT must be positive, got %s", c.Server.ShutdownTimeout)
+
+	errs = append(errs, c.Database.validate()...)
+
+	switch c.Cache.Driver {
+	case cache.DriverRedis:
+		check(c.Cache.RedisAddr != "", "REDIS_ADDR is required when CACHE_DRIVER is %q", cache.DriverRedis)
+		check(c.Cache.FailureThreshold > 0, "CACHE_FAILURE_THRESHOLD must be positive, got %d", c.Cache.FailureThreshold)
+		check(c.Cache.ReconnectInterval > 0, "CACHE_RECONNECT_INTERVAL must be positive, got %s", c.Cache.ReconnectInterval)
+	case cache.DriverMemory:
+		check(c.Cache.MemoryCapacity > 0, "CACHE_MEMORY_CAPACITY must be positive, got %d", c.Cache.MemoryCapacity)
+	default:
+		check(false, "CACHE_DRIVER must be %q or %q, got %q", cache.DriverRedis, cache.DriverMemory, c.Cache.Driver)
+	}
+
+	check(c.RateLimit.Requests > 0, "RATE_LIMIT_REQUESTS must be positive, got %d", c.RateLimit.Requests)
+	check(c.RateLimit.Period > 0, "RATE_LIMIT_PERIOD must be positive, got %s", c.RateLimit.Period)
+	check(c.ImportMaxBytes > 0, "IMPORT_MAX_BYTES must be positive, got %d", c.ImportMaxBytes)
+	check(c.SoftDeleteRetention >= time.Hour, "SOFT_DELETE_RETENTION must be at least 1h, got %s", c.SoftDeleteRetention)
+	check(c.Matrix.MaxPoints > 0, "MATRIX_MAX_POINTS must be positive, got %d", c.Matrix.MaxPoints)
+	check(c.Matrix.MaxElements > 0, "MATRIX_MAX_ELEMENTS must be positive, got %d", c.Matrix.MaxElements)
+	check(c.Matrix.SpeedKmh > 0, "MATRIX_SPEED_KMH must be positive, got %g", c.Matrix.SpeedKmh)
+
+	return errors.Join(errs...)
+}
+
+func (c DatabaseConfig) validate() []error {
+	var errs []error
+	switch c.Driver {
+	case DriverMySQL, DriverPostgres:
+		if c.Host == "" {
+			errs = append(errs, errors.New("DB_HOST is required"))
+		}
+		if c.Port < 1 || c.Port > 65535 {
+			errs = append(errs, fmt.Errorf("DB_PORT must be between 1 and 65535, got %d", c.Port))
+		}
+		if c.User == "" {
+			errs = append(errs, errors.New("DB_USER is required"))
+		}
+		if c.Name == "" {
+			errs = append(errs, errors.New("DB_NAME is required"))
+		}
+	case DriverSQLite:
+		if c.SQLitePath == "" {
+			errs = append(errs, fmt.Errorf("SQLITE_PATH is required when DB_DRIVER is %q", DriverSQLite))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("DB_DRIVER must be %q, %q or %q, got %q", DriverMySQL, DriverPostgres, DriverSQLite, c.Driver))
+	}
+	return errs
+}
+
+// envReader reads typed values from the environment, collecting an error for
+// every malformed value instead of silently falling back.
+type envReader struct {
+	getenv func(string) string
+	errs   []error
+}
+
+func (r *envReader) database() DatabaseConfig {
+	return DatabaseConfig{
+		Driver:     r.string("DB_DRIVER", DriverMySQL),
+		SQLitePath: r.string("SQLITE_PATH", "locations.db"),
+		Host:       r.string("DB_HOST", ""),
+		Port:       r.int("DB_PORT", 0),
+		User:       r.string("DB_USER", ""),
+		Password:   r.string("DB_PASSWORD", ""),
+		Name:       r.string("DB_NAME", ""),
+		SSLMode:    r.string("DB_SSLMODE", "disable"),
+	}
+}
+
+func (r *envReader) string(key, fallback string) string {
+	if value := r.getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func (r *envReader) int(key string, fallback int) int {
+	value := r.getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be an integer, got %q", key, value))
+		return fallback
+	}
+	return n
+}
+
+func (r *envReader) float(key string, fallback float64) float64 {
+	value := r.getenv(key)
+	if value == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be a number, got %q", key, value))
+		return fallback
+	}
+	return f
+}
+
+func (r *envReader) duration(key string, fallback time.Duration) time.Duration {
+	value := r.getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be a duration such as 30s or 720h, got %q", key, value))
+		return fallback
+	}
+	return d
+}
+
+func (r *envReader) bool(key string, fallback bool) bool {
+	value := r.getenv(key)
+	if value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("%s must be true or false, got %q", key, value))
+		return fallback
+	}
+	return b
+}
@@ -2,45 +2,42 @@ package config
 
 import (
 	"fmt"
-	"github.com/yusufbulac/location-routing-service/internal/model"
 	"log"
-	"os"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
-
-func ConnectDatabase() {
-	LoadEnv(".env")
-
-	dsn := fmt.Sprintf(
-		"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		getEnv("DB_USER", ""),
-		getEnv("DB_PASSWORD", ""),
-		getEnv("DB_HOST", ""),
-		getEnv("DB_PORT", ""),
-		getEnv("DB_NAME", ""),
-	)
-
-	database, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+// ConnectDatabase opens the database described by cfg. The schema is managed
+// by the migrations package and is not changed here.
+func ConnectDatabase(cfg DatabaseConfig) (*gorm.DB, error) {
+	database, err := gorm.Open(dialector(cfg), &gorm.Config{})
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		return nil, fmt.Errorf("connect to %s database: %w", cfg.Driver, err)
 	}
 
-	err = database.AutoMigrate(&model.Location{})
-	if err != nil {
-		log.Fatal("Migration failed:", err)
+	if cfg.Driver == DriverSQLite {
+		// SQLite serialises writers; a single connection avoids lock contention
+		sqlDB, err := database.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
 	}
 
-	DB = database
-	fmt.Println("Database connection successful")
+	log.Printf("Database connection successful (%s)", cfg.Driver)
+	return database, nil
 }
 
-func getEnv(key, fallback string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+func dialector(cfg DatabaseConfig) gorm.Dialector {
+	switch cfg.Driver {
+	case DriverSQLite:
+		return sqlite.Open(cfg.DSN())
+	case DriverPostgres:
+		return postgres.Open(cfg.DSN())
+	default:
+		return mysql.Open(cfg.DSN())
 	}
-	return fallback
 }
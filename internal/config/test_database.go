@@ -1,31 +1,13 @@
 package config
 
 import (
-	"fmt"
-	"log"
+	"io"
 	"os"
-
-	"gorm.io/driver/mysql"
-	"gorm.io/gorm"
 )
 
-func ConnectTestDatabase() *gorm.DB {
+// LoadTestConfig reads the integration test configuration from .env.test and
+// the environment, applying the command-line style overrides in args.
+func LoadTestConfig(args ...string) (*Config, error) {
 	LoadEnv(".env.test")
-
-	dsn := fmt.Sprintf(
-		"%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_NAME"),
-	)
-
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
-	if err != nil {
-		log.Fatalf("Failed to connect to test database: %v", err)
-	}
-
-	log.Println("Test database connection successful")
-	return db
+	return load(args, os.Getenv, io.Discard)
 }
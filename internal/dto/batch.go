@@ -0,0 +1,33 @@
+package dto
+
+import "github.com/yusufbulac/location-routing-service/internal/model"
+
+const (
+	// BatchModeAtomic stores nothing if any item is invalid.
+	BatchModeAtomic = "atomic"
+	// BatchModeBestEffort stores the valid items and reports the invalid ones.
+	BatchModeBestEffort = "best_effort"
+)
+
+type BatchLocationRequest struct {
+	Mode  string            `json:"mode" validate:"omitempty,oneof=atomic best_effort" example:"atomic"`
+	Items []LocationRequest `json:"items" validate:"required,min=1"`
+}
+
+// BatchItemError reports why the item at Index of the request was rejected.
+type BatchItemError struct {
+	Index   int    `json:"index"`
+	Details string `json:"details"`
+}
+
+// BatchCreatedLocation is the stored location for the item at Index of the request.
+type BatchCreatedLocation struct {
+	Index    int            `json:"index"`
+	Location model.Location `json:"location"`
+}
+
+type BatchLocationResponse struct {
+	Mode    string                 `json:"mode"`
+	Created []BatchCreatedLocation `json:"created"`
+	Errors  []BatchItemError       `json:"errors"`
+}
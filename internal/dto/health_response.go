@@ -0,0 +1,13 @@
+package dto
+
+type CacheHealth struct {
+	Driver    string `json:"driver"`
+	Available bool   `json:"available"`
+	Circuit   string `json:"circuit,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+type HealthResponse struct {
+	Status string      `json:"status"`
+	Cache  CacheHealth `json:"cache"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+// ImportRowError reports why a record of an imported file was rejected.
+// Index counts the records (CSV rows, GeoJSON features) from 0; Row is the
+// line of a CSV row, where the header is row 1.
+type ImportRowError struct {
+	Index   int    `json:"index"`
+	Row     int    `json:"row,omitempty"`
+	Details string `json:"details"`
+}
+
+// ImportResponse summarises an import. Valid counts the rows that passed
+// validation; Created counts the rows stored, 0 for a dry run or a rejected import.
+type ImportResponse struct {
+	Mode            string           `json:"mode"`
+	DryRun          bool             `json:"dry_run"`
+	Rows            int              `json:"rows"`
+	Valid           int              `json:"valid"`
+	Invalid         int              `json:"invalid"`
+	Created         int              `json:"created"`
+	Errors          []ImportRowError `json:"errors"`
+	ErrorsTruncated bool             `json:"errors_truncated,omitempty"`
+}
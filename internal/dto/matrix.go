@@ -0,0 +1,74 @@
+package dto
+
+import (
+	"errors"
+
+	"github.com/yusufbulac/location-routing-service/internal/model"
+)
+
+// MatrixRequest lists the origins and destinations of a distance matrix.
+// Without destinations the matrix is square over the origins.
+type MatrixRequest struct {
+	Origins      []MatrixPointRequest `json:"origins" validate:"required,min=1,dive"`
+	Destinations []MatrixPointRequest `json:"destinations" validate:"omitempty,dive"`
+	// SpeedKmh is the average speed durations are estimated with; zero uses
+	// the server default.
+	SpeedKmh float64 `json:"speed_kmh" validate:"omitempty,gt=0,lte=1000" example:"50"`
+}
+
+// MatrixPointRequest is either a stored location (ID) or a point given by
+// both coordinates.
+type MatrixPointRequest struct {
+	ID        uint     `json:"id" example:"3"`
+	Latitude  *float64 `json:"latitude" validate:"omitempty,gte=-90,lte=90" example:"41.0082"`
+	Longitude *float64 `json:"longitude" validate:"omitempty,gte=-180,lte=180" example:"28.9784"`
+}
+
+// Point returns the matrix point p names.
+func (p MatrixPointRequest) Point() (model.MatrixPoint, error) {
+	hasCoordinates := p.Latitude != nil || p.Longitude != nil
+	switch {
+	case p.ID != 0 && hasCoordinates:
+		return model.MatrixPoint{}, errors.New("give either id or latitude and longitude, not both")
+	case p.ID != 0:
+		return model.MatrixPoint{LocationID: p.ID}, nil
+	case p.Latitude == nil || p.Longitude == nil:
+		return model.MatrixPoint{}, errors.New("give id or both latitude and longitude")
+	}
+	return model.MatrixPoint{Latitude: *p.Latitude, Longitude: *p.Longitude}, nil
+}
+
+// MatrixResponse holds the distances in Unit and the durations in seconds
+// from every origin (row) to every destination (column).
+type MatrixResponse struct {
+	Origins      []model.MatrixPoint `json:"origins"`
+	Destinations []model.MatrixPoint `json:"destinations"`
+	Distances    [][]float64         `json:"distances"`
+	Durations    [][]float64         `json:"durations"`
+	Unit         DistanceUnit        `json:"unit"`
+	SpeedKmh     float64             `json:"speed_kmh"`
+}
+
+// NewMatrixResponse converts a distance matrix into its response form with
+// distances in unit.
+func NewMatrixResponse(matrix *model.DistanceMatrix, unit DistanceUnit) MatrixResponse {
+	distances := matrix.Distances
+	if unit != UnitKilometers {
+		distances = make([][]float64, len(matrix.Distances))
+		for i, row := range matrix.Distances {
+			distances[i] = make([]float64, len(row))
+			for j, km := range row {
+				distances[i][j] = unit.FromKilometers(km)
+			}
+		}
+	}
+
+	return MatrixResponse{
+		Origins:      matrix.Origins,
+		Destinations: matrix.Destinations,
+		Distances:    distances,
+		Durations:    matrix.Durations,
+		Unit:         unit,
+		SpeedKmh:     matrix.SpeedKmh,
+	}
+}
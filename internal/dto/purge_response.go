@@ -0,0 +1,6 @@
+package dto
+
+type PurgeResponse struct {
+	Purged    int64  `json:"purged"`
+	OlderThan string `json:"older_than"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+// RouteRequest selects the locations of a route and where it starts: at the
+// point Start or at the location StartID, such as a depot. Locations must
+// match both LocationIDs and Colors when both are given.
+type RouteRequest struct {
+	LocationIDs   []uint      `json:"location_ids" validate:"omitempty,dive,gt=0" example:"3,8,21"`
+	Colors        []string    `json:"colors" validate:"omitempty,max=50,dive,hexcolor" example:"#ff0000"`
+	Start         *RoutePoint `json:"start" validate:"required_without=StartID,excluded_with=StartID"`
+	StartID       uint        `json:"start_id" example:"1"`
+	EndID         uint        `json:"end_id"`
+	ReturnToStart bool        `json:"return_to_start"`
+}
+
+type RoutePoint struct {
+	Latitude  float64 `json:"latitude" validate:"gte=-90,lte=90" example:"41.0082"`
+	Longitude float64 `json:"longitude" validate:"gte=-180,lte=180" example:"28.9784"`
+}
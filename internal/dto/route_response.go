@@ -0,0 +1,83 @@
+package dto
+
+import (
+	"fmt"
+
+	"github.com/yusufbulac/location-routing-service/internal/model"
+)
+
+type DistanceUnit string
+
+const (
+	UnitKilometers    DistanceUnit = "km"
+	UnitMiles         DistanceUnit = "mi"
+	UnitNauticalMiles DistanceUnit = "nmi"
+)
+
+// kilometres per unit
+var unitFactors = map[DistanceUnit]float64{
+	UnitKilometers:    1,
+	UnitMiles:         1.609344,
+	UnitNauticalMiles: 1.852,
+}
+
+// ParseDistanceUnit validates a unit query value; an empty value means kilometres.
+func ParseDistanceUnit(s string) (DistanceUnit, error) {
+	if s == "" {
+		return UnitKilometers, nil
+	}
+	unit := DistanceUnit(s)
+	if _, ok := unitFactors[unit]; !ok {
+		return "", fmt.Errorf("unsupported unit %q (expected km, mi or nmi)", s)
+	}
+	return unit, nil
+}
+
+// FromKilometers converts a distance in kilometres into the unit.
+func (u DistanceUnit) FromKilometers(km float64) float64 {
+	return km / unitFactors[u]
+}
+
+type RouteStopResponse struct {
+	Sequence           int            `json:"sequence"`
+	Location           model.Location `json:"location"`
+	LegDistance        float64        `json:"leg_distance"`
+	CumulativeDistance float64        `json:"cumulative_distance"`
+	Bearing            float64        `json:"bearing"`
+}
+
+type RouteSummary struct {
+	TotalDistance float64      `json:"total_distance"`
+	StopCount     int          `json:"stop_count"`
+	Unit          DistanceUnit `json:"unit"`
+}
+
+type RouteResponse struct {
+	Start   *model.Location     `json:"start,omitempty"`
+	Stops   []RouteStopResponse `json:"stops"`
+	Summary RouteSummary        `json:"summary"`
+}
+
+// NewRouteResponse converts a route into its response form with distances in unit.
+func NewRouteResponse(route *model.Route, unit DistanceUnit) RouteResponse {
+	stops := make([]RouteStopResponse, 0, len(route.Stops))
+	for i, stop := range route.Stops {
+		stops = append(stops, RouteStopResponse{
+			Sequence:           i + 1,
+			Location:           stop.Location,
+			LegDistance:        unit.FromKilometers(stop.LegDistance),
+			CumulativeDistance: unit.FromKilometers(stop.CumulativeDistance),
+			Bearing:            stop.Bearing,
+		})
+	}
+
+	return RouteResponse{
+		Start: route.Start,
+		Stops: stops,
+		Summary: RouteSummary{
+			TotalDistance: unit.FromKilometers(route.TotalDistance),
+			StopCount:     len(stops),
+			Unit:          unit,
+		},
+	}
+}
@@ -0,0 +1,61 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+)
+
+func TestParseDistanceUnit(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		want  DistanceUnit
+	}{
+		{"", UnitKilometers},
+		{"km", UnitKilometers},
+		{"mi", UnitMiles},
+		{"nmi", UnitNauticalMiles},
+	} {
+		unit, err := ParseDistanceUnit(tc.value)
+		assert.NoError(t, err, tc.value)
+		assert.Equal(t, tc.want, unit, tc.value)
+	}
+
+	for _, value := range []string{"m", "KM", "miles", "furlong"} {
+		_, err := ParseDistanceUnit(value)
+		assert.ErrorContains(t, err, "unsupported unit", value)
+	}
+}
+
+func TestNewRouteResponse_Units(t *testing.T) {
+	route := &model.Route{
+		Stops: []model.RouteStop{
+			{Location: model.Location{ID: 1}, LegDistance: 10, CumulativeDistance: 10, Bearing: 90},
+			{Location: model.Location{ID: 2}, LegDistance: 5, CumulativeDistance: 15, Bearing: 180},
+		},
+		TotalDistance: 15,
+	}
+
+	for _, tc := range []struct {
+		unit             DistanceUnit
+		legs, cumulative []float64
+		total            float64
+	}{
+		{UnitKilometers, []float64{10, 5}, []float64{10, 15}, 15},
+		{UnitMiles, []float64{6.213712, 3.106856}, []float64{6.213712, 9.320568}, 9.320568},
+		{UnitNauticalMiles, []float64{5.399568, 2.699784}, []float64{5.399568, 8.099352}, 8.099352},
+	} {
+		response := NewRouteResponse(route, tc.unit)
+
+		assert.Equal(t, tc.unit, response.Summary.Unit)
+		assert.Equal(t, 2, response.Summary.StopCount)
+		assert.InDelta(t, tc.total, response.Summary.TotalDistance, 1e-6, tc.unit)
+		for i, stop := range response.Stops {
+			assert.Equal(t, i+1, stop.Sequence)
+			assert.InDelta(t, tc.legs[i], stop.LegDistance, 1e-6, tc.unit)
+			assert.InDelta(t, tc.cumulative[i], stop.CumulativeDistance, 1e-6, tc.unit)
+			assert.Equal(t, route.Stops[i].Bearing, stop.Bearing, "bearings do not depend on the unit")
+		}
+	}
+}
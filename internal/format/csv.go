@@ -0,0 +1,212 @@
+// Package format converts locations to and from file formats used outside
+// the JSON API.
+package format
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yusufbulac/location-routing-service/internal/dto"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+)
+
+// Location fields that CSV columns map to.
+const (
+	FieldName      = "name"
+	FieldLatitude  = "latitude"
+	FieldLongitude = "longitude"
+	FieldColor     = "color"
+)
+
+var csvFields = []string{FieldName, FieldLatitude, FieldLongitude, FieldColor}
+
+// csvAliases are the header names recognised for each field, compared
+// case-insensitively.
+var csvAliases = map[string][]string{
+	FieldName:      {"name", "title", "label"},
+	FieldLatitude:  {"latitude", "lat"},
+	FieldLongitude: {"longitude", "lng", "lon", "long"},
+	FieldColor:     {"color", "colour"},
+}
+
+// CSVExportHeader is the header row written by CSVWriter.
+var CSVExportHeader = []string{"id", "name", "latitude", "longitude", "color", "created_at", "updated_at"}
+
+// CSVDecoder reads location requests from CSV one row at a time. The first
+// row is a header naming the columns. Positions carry the line of each row,
+// which matches the row number shown by spreadsheets.
+type CSVDecoder struct {
+	r        *csv.Reader
+	columns  map[string]int
+	position Position
+}
+
+// NewCSVDecoder reads the header row of r. mapping optionally names the
+// header column of a field ("name", "latitude", "longitude" or "color");
+// unmapped fields are found by their usual header names.
+func NewCSVDecoder(r io.Reader, mapping map[string]string) (*CSVDecoder, error) {
+	for field := range mapping {
+		if _, ok := csvAliases[field]; !ok {
+			return nil, fmt.Errorf("unknown field %q in column mapping (expected name, latitude, longitude or color)", field)
+		}
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("empty CSV: a header row is required")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read CSV header: %w", err)
+	}
+
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			// spreadsheet exports often start with a UTF-8 byte order mark
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, dup := positions[name]; !dup {
+			positions[name] = i
+		}
+	}
+
+	d := &CSVDecoder{r: cr, columns: make(map[string]int, len(csvFields)), position: Position{Index: -1}}
+	var missing []string
+	for _, field := range csvFields {
+		candidates := csvAliases[field]
+		if column, ok := mapping[field]; ok {
+			candidates = []string{column}
+		}
+		found := false
+		for _, candidate := range candidates {
+			if i, ok := positions[strings.ToLower(strings.TrimSpace(candidate))]; ok {
+				d.columns[field], found = i, true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, fmt.Sprintf("%s (looked for %s)", field, strings.Join(candidates, ", ")))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("CSV header is missing columns: %s", strings.Join(missing, "; "))
+	}
+	return d, nil
+}
+
+// Next returns the next row as a location request; blank rows are skipped.
+func (d *CSVDecoder) Next() (dto.LocationRequest, error) {
+	for {
+		record, err := d.r.Read()
+		if errors.Is(err, io.EOF) {
+			return dto.LocationRequest{}, io.EOF
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			d.position = Position{Index: d.position.Index + 1, Line: parseErr.StartLine}
+			return dto.LocationRequest{}, &RecordError{Position: d.position, Err: parseErr.Err}
+		}
+		if err != nil {
+			return dto.LocationRequest{}, err
+		}
+		if isBlank(record) {
+			continue
+		}
+		line, _ := d.r.FieldPos(0)
+		d.position = Position{Index: d.position.Index + 1, Line: line}
+		return d.decode(record)
+	}
+}
+
+func (d *CSVDecoder) Position() Position {
+	return d.position
+}
+
+func (d *CSVDecoder) decode(record []string) (dto.LocationRequest, error) {
+	value := func(field string) string {
+		if i := d.columns[field]; i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var errs []error
+	coordinate := func(field string) float64 {
+		raw := value(field)
+		if raw == "" {
+			return 0
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %q is not a number", field, raw))
+		}
+		return v
+	}
+
+	req := dto.LocationRequest{
+		Name:      value(FieldName),
+		Latitude:  coordinate(FieldLatitude),
+		Longitude: coordinate(FieldLongitude),
+		Color:     value(FieldColor),
+	}
+	if len(errs) > 0 {
+		return dto.LocationRequest{}, &RecordError{Position: d.position, Err: errors.Join(errs...)}
+	}
+	return req, nil
+}
+
+func isBlank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// CSVWriter writes locations as CSV rows below CSVExportHeader.
+type CSVWriter struct {
+	w      *csv.Writer
+	header bool
+	record []string
+}
+
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w), record: make([]string, len(CSVExportHeader))}
+}
+
+// Write writes locations, preceded by the header on the first call, and
+// flushes them to the underlying writer.
+func (cw *CSVWriter) Write(locations []model.Location) error {
+	if !cw.header {
+		if err := cw.w.Write(CSVExportHeader); err != nil {
+			return err
+		}
+		cw.header = true
+	}
+	for _, loc := range locations {
+		cw.record[0] = strconv.FormatUint(uint64(loc.ID), 10)
+		cw.record[1] = loc.Name
+		cw.record[2] = strconv.FormatFloat(loc.Latitude, 'f', -1, 64)
+		cw.record[3] = strconv.FormatFloat(loc.Longitude, 'f', -1, 64)
+		cw.record[4] = loc.Color
+		cw.record[5] = loc.CreatedAt.UTC().Format(time.RFC3339)
+		cw.record[6] = loc.UpdatedAt.UTC().Format(time.RFC3339)
+		if err := cw.w.Write(cw.record); err != nil {
+			return err
+		}
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
@@ -0,0 +1,112 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yusufbulac/location-routing-service/internal/dto"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+)
+
+// decodeAll returns the decoded requests and the record errors of d.
+func decodeAll(t *testing.T, d Decoder) ([]dto.LocationRequest, []*RecordError) {
+	t.Helper()
+	var (
+		requests []dto.LocationRequest
+		recErrs  []*RecordError
+	)
+	for {
+		req, err := d.Next()
+		if errors.Is(err, io.EOF) {
+			return requests, recErrs
+		}
+		var recErr *RecordError
+		if errors.As(err, &recErr) {
+			recErrs = append(recErrs, recErr)
+			continue
+		}
+		require.NoError(t, err)
+		requests = append(requests, req)
+	}
+}
+
+func TestCSVDecoder_HeaderAliases(t *testing.T) {
+	input := "\ufeffLabel,Colour,Lng,Lat,notes\n" +
+		"Istanbul,#ff0000,28.9784,41.0082,ignored\n" +
+		"\n" +
+		"  Ankara , #00ff00 ,32.8597,39.9334\n"
+
+	d, err := NewCSVDecoder(strings.NewReader(input), nil)
+	require.NoError(t, err)
+	requests, recErrs := decodeAll(t, d)
+
+	assert.Empty(t, recErrs)
+	assert.Equal(t, []dto.LocationRequest{
+		{Name: "Istanbul", Latitude: 41.0082, Longitude: 28.9784, Color: "#ff0000"},
+		{Name: "Ankara", Latitude: 39.9334, Longitude: 32.8597, Color: "#00ff00"},
+	}, requests)
+	assert.Equal(t, Position{Index: 1, Line: 4}, d.Position(), "blank lines are skipped but counted")
+}
+
+func TestCSVDecoder_ColumnMapping(t *testing.T) {
+	input := "Store,Y,X,Marker\nDepot,40.5,29.5,#123456\n"
+
+	_, err := NewCSVDecoder(strings.NewReader(input), nil)
+	assert.ErrorContains(t, err, "missing columns: name (looked for name, title, label)")
+
+	d, err := NewCSVDecoder(strings.NewReader(input), map[string]string{
+		"name": "store", "latitude": "Y", "longitude": "X", "color": "Marker",
+	})
+	require.NoError(t, err)
+	requests, _ := decodeAll(t, d)
+	assert.Equal(t, []dto.LocationRequest{{Name: "Depot", Latitude: 40.5, Longitude: 29.5, Color: "#123456"}}, requests)
+
+	_, err = NewCSVDecoder(strings.NewReader(input), map[string]string{"elevation": "Z"})
+	assert.ErrorContains(t, err, `unknown field "elevation"`)
+}
+
+func TestCSVDecoder_RowErrors(t *testing.T) {
+	input := "name,latitude,longitude,color\n" +
+		"A,north,29,#ffffff\n" +
+		"B,\"41,29,#ffffff\n"
+	d, err := NewCSVDecoder(strings.NewReader(input), nil)
+	require.NoError(t, err)
+
+	_, recErrs := decodeAll(t, d)
+	require.Len(t, recErrs, 2)
+	assert.Equal(t, Position{Index: 0, Line: 2}, recErrs[0].Position)
+	assert.EqualError(t, recErrs[0], `line 2: latitude: "north" is not a number`)
+	assert.Equal(t, Position{Index: 1, Line: 3}, recErrs[1].Position)
+}
+
+func TestCSVDecoder_EmptyInput(t *testing.T) {
+	_, err := NewCSVDecoder(strings.NewReader(""), nil)
+	assert.EqualError(t, err, "empty CSV: a header row is required")
+}
+
+func TestCSVWriter(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	w := NewCSVWriter(&buf)
+
+	require.NoError(t, w.Write([]model.Location{
+		{ID: 1, Name: "Depot, North", Latitude: 41.0082, Longitude: 28.9784, Color: "#ff0000", CreatedAt: created, UpdatedAt: created},
+	}))
+	require.NoError(t, w.Write([]model.Location{{ID: 2, Name: "B", Latitude: -1.5, Longitude: 2, Color: "#00ff00", CreatedAt: created, UpdatedAt: created}}))
+
+	assert.Equal(t, "id,name,latitude,longitude,color,created_at,updated_at\n"+
+		"1,\"Depot, North\",41.0082,28.9784,#ff0000,2024-05-01T12:00:00Z,2024-05-01T12:00:00Z\n"+
+		"2,B,-1.5,2,#00ff00,2024-05-01T12:00:00Z,2024-05-01T12:00:00Z\n", buf.String())
+
+	d, err := NewCSVDecoder(&buf, nil)
+	require.NoError(t, err)
+	requests, recErrs := decodeAll(t, d)
+	assert.Empty(t, recErrs)
+	assert.Equal(t, "Depot, North", requests[0].Name, "exports can be imported again")
+}
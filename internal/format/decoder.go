@@ -0,0 +1,46 @@
+package format
+
+import (
+	"fmt"
+
+	"github.com/yusufbulac/location-routing-service/internal/dto"
+)
+
+// Decoder reads location requests from an imported file one record at a time.
+type Decoder interface {
+	// Next returns the next record. It returns a *RecordError for a record
+	// that cannot be read, after which decoding may continue, and io.EOF
+	// after the last record. Fields are not validated beyond parsing.
+	Next() (dto.LocationRequest, error)
+	// Position locates the record last returned by Next.
+	Position() Position
+}
+
+// Position locates a record in an imported file.
+type Position struct {
+	// Index counts the records of the file from 0.
+	Index int
+	// Line is the line the record starts on, 0 for formats without lines.
+	Line int
+}
+
+func (p Position) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("line %d", p.Line)
+	}
+	return fmt.Sprintf("record %d", p.Index)
+}
+
+// RecordError is a problem with a single record of an imported file.
+type RecordError struct {
+	Position
+	Err error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Position, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
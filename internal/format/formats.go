@@ -0,0 +1,59 @@
+package format
+
+import (
+	"mime"
+	"path"
+	"strings"
+)
+
+// Names of the supported file formats, as used by the format query parameter.
+const (
+	CSV     = "csv"
+	GeoJSON = "geojson"
+	GPX     = "gpx"
+	KML     = "kml"
+)
+
+// Media types of the supported file formats.
+const (
+	MIMECSV = "text/csv"
+	MIMEGPX = "application/gpx+xml"
+	MIMEKML = "application/vnd.google-earth.kml+xml"
+)
+
+// FromMediaType returns the format of a Content-Type value, or "" if it is
+// not one of the supported formats. Plain JSON is read as GeoJSON.
+func FromMediaType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	switch mediaType {
+	case MIMECSV:
+		return CSV
+	case MIMEGeoJSON, "application/json":
+		return GeoJSON
+	case MIMEGPX:
+		return GPX
+	case MIMEKML:
+		return KML
+	default:
+		return ""
+	}
+}
+
+// FromFilename returns the format of a file by its extension, or "".
+func FromFilename(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".csv":
+		return CSV
+	case ".geojson", ".json":
+		return GeoJSON
+	case ".gpx":
+		return GPX
+	case ".kml":
+		return KML
+	default:
+		return ""
+	}
+}
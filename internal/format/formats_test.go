@@ -0,0 +1,18 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatDetection(t *testing.T) {
+	assert.Equal(t, GPX, FromMediaType("application/gpx+xml; charset=utf-8"))
+	assert.Equal(t, KML, FromMediaType(MIMEKML))
+	assert.Equal(t, GeoJSON, FromMediaType("application/json"))
+	assert.Equal(t, "", FromMediaType("text/plain"))
+
+	assert.Equal(t, GPX, FromFilename("Tour.GPX"))
+	assert.Equal(t, KML, FromFilename("pins.kml"))
+	assert.Equal(t, "", FromFilename("pins.kmz"))
+}
@@ -0,0 +1,259 @@
+package format
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/yusufbulac/location-routing-service/internal/dto"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+)
+
+// MIMEGeoJSON is the media type of GeoJSON documents (RFC 7946).
+const MIMEGeoJSON = "application/geo+json"
+
+// FeatureCollection is a GeoJSON feature collection.
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is a GeoJSON feature. Locations use their ID as the feature ID.
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         uint                   `json:"id,omitempty"`
+	Geometry   Geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Geometry is a GeoJSON Point or LineString. Positions are [longitude, latitude].
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+func point(lat, lng float64) Geometry {
+	return Geometry{Type: "Point", Coordinates: []float64{lng, lat}}
+}
+
+func newFeatureCollection(capacity int) FeatureCollection {
+	return FeatureCollection{Type: "FeatureCollection", Features: make([]Feature, 0, capacity)}
+}
+
+// LocationsGeoJSON returns locations as Point features with their name and
+// color as properties.
+func LocationsGeoJSON(locations []model.Location) FeatureCollection {
+	fc := newFeatureCollection(len(locations))
+	for _, loc := range locations {
+		fc.Features = append(fc.Features, Feature{
+			Type:     "Feature",
+			ID:       loc.ID,
+			Geometry: point(loc.Latitude, loc.Longitude),
+			Properties: map[string]interface{}{
+				"name":  loc.Name,
+				"color": loc.Color,
+			},
+		})
+	}
+	return fc
+}
+
+// RouteGeoJSON returns a route from (originLat, originLng) as a LineString
+// of the whole path followed by the stops in visiting order as Point features.
+// A route anchored at a start location begins there instead, and the start
+// location precedes the stops with sequence 0. A route without stops has no
+// LineString.
+func RouteGeoJSON(route dto.RouteResponse, originLat, originLng float64) FeatureCollection {
+	fc := newFeatureCollection(len(route.Stops) + 2)
+	originLat, originLng = routeOrigin(route, originLat, originLng)
+
+	if len(route.Stops) > 0 {
+		path := make([][]float64, 0, len(route.Stops)+1)
+		path = append(path, []float64{originLng, originLat})
+		for _, stop := range route.Stops {
+			path = append(path, []float64{stop.Location.Longitude, stop.Location.Latitude})
+		}
+		fc.Features = append(fc.Features, Feature{
+			Type:     "Feature",
+			Geometry: Geometry{Type: "LineString", Coordinates: path},
+			Properties: map[string]interface{}{
+				"total_distance": route.Summary.TotalDistance,
+				"stop_count":     route.Summary.StopCount,
+				"unit":           route.Summary.Unit,
+			},
+		})
+	}
+
+	if route.Start != nil {
+		fc.Features = append(fc.Features, Feature{
+			Type:     "Feature",
+			ID:       route.Start.ID,
+			Geometry: point(route.Start.Latitude, route.Start.Longitude),
+			Properties: map[string]interface{}{
+				"sequence": 0,
+				"name":     route.Start.Name,
+				"color":    route.Start.Color,
+			},
+		})
+	}
+	for _, stop := range route.Stops {
+		fc.Features = append(fc.Features, Feature{
+			Type:     "Feature",
+			ID:       stop.Location.ID,
+			Geometry: point(stop.Location.Latitude, stop.Location.Longitude),
+			Properties: map[string]interface{}{
+				"sequence":            stop.Sequence,
+				"name":                stop.Location.Name,
+				"color":               stop.Location.Color,
+				"leg_distance":        stop.LegDistance,
+				"cumulative_distance": stop.CumulativeDistance,
+				"bearing":             stop.Bearing,
+			},
+		})
+	}
+	return fc
+}
+
+// routeOrigin returns the position a route starts at: its start location if
+// it has one, otherwise the reference point.
+func routeOrigin(route dto.RouteResponse, lat, lng float64) (float64, float64) {
+	if route.Start != nil {
+		return route.Start.Latitude, route.Start.Longitude
+	}
+	return lat, lng
+}
+
+// GeoJSONDecoder reads location requests from the Point features of a
+// FeatureCollection, one feature at a time. The name comes from the "name"
+// (or "title") property and the color from "color" (or "marker-color").
+type GeoJSONDecoder struct {
+	dec      *json.Decoder
+	position Position
+	done     bool
+}
+
+type inputFeature struct {
+	Type     string `json:"type"`
+	Geometry *struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// NewGeoJSONDecoder reads r up to the first feature of its "features" array.
+func NewGeoJSONDecoder(r io.Reader) (*GeoJSONDecoder, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("read GeoJSON: %w", err)
+		}
+		switch token {
+		case "type":
+			var typ string
+			if err := dec.Decode(&typ); err != nil || typ != "FeatureCollection" {
+				return nil, errors.New(`GeoJSON must be a FeatureCollection`)
+			}
+		case "features":
+			if err := expectDelim(dec, '['); err != nil {
+				return nil, err
+			}
+			return &GeoJSONDecoder{dec: dec, position: Position{Index: -1}}, nil
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, fmt.Errorf("read GeoJSON: %w", err)
+			}
+		}
+	}
+	return nil, errors.New(`GeoJSON FeatureCollection has no "features" array`)
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("read GeoJSON: %w", err)
+	}
+	if token != delim {
+		return fmt.Errorf("read GeoJSON: expected %q, found %v", delim, token)
+	}
+	return nil
+}
+
+// Next returns the location request of the next feature. Features after the
+// "features" array are not read.
+func (d *GeoJSONDecoder) Next() (dto.LocationRequest, error) {
+	if d.done || !d.dec.More() {
+		d.done = true
+		return dto.LocationRequest{}, io.EOF
+	}
+
+	var feature inputFeature
+	if err := d.dec.Decode(&feature); err != nil {
+		// the stream cannot be resynchronised after malformed JSON
+		return dto.LocationRequest{}, fmt.Errorf("read GeoJSON feature %d: %w", d.position.Index+1, err)
+	}
+	d.position.Index++
+
+	req, err := decodeFeature(feature)
+	if err != nil {
+		return dto.LocationRequest{}, &RecordError{Position: d.position, Err: err}
+	}
+	return req, nil
+}
+
+func (d *GeoJSONDecoder) Position() Position {
+	return d.position
+}
+
+func decodeFeature(feature inputFeature) (dto.LocationRequest, error) {
+	if feature.Type != "Feature" {
+		return dto.LocationRequest{}, fmt.Errorf("type %q is not a Feature", feature.Type)
+	}
+	if feature.Geometry == nil || feature.Geometry.Type != "Point" {
+		return dto.LocationRequest{}, errors.New("geometry is not a Point")
+	}
+
+	var position []float64
+	if err := json.Unmarshal(feature.Geometry.Coordinates, &position); err != nil || len(position) < 2 {
+		return dto.LocationRequest{}, errors.New("Point coordinates must be [longitude, latitude]")
+	}
+
+	name, err := stringProperty(feature.Properties, "name", "title")
+	if err != nil {
+		return dto.LocationRequest{}, err
+	}
+	color, err := stringProperty(feature.Properties, "color", "marker-color")
+	if err != nil {
+		return dto.LocationRequest{}, err
+	}
+
+	return dto.LocationRequest{
+		Name:      name,
+		Latitude:  position[1],
+		Longitude: position[0],
+		Color:     color,
+	}, nil
+}
+
+// stringProperty returns the first of keys present in properties.
+func stringProperty(properties map[string]interface{}, keys ...string) (string, error) {
+	for _, key := range keys {
+		value, ok := properties[key]
+		if !ok || value == nil {
+			continue
+		}
+		s, ok := value.(string)
+		if !ok {
+			return "", fmt.Errorf("property %q must be a string", key)
+		}
+		return s, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,105 @@
+package format
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yusufbulac/location-routing-service/internal/dto"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+)
+
+func TestLocationsGeoJSON(t *testing.T) {
+	fc := LocationsGeoJSON([]model.Location{{ID: 7, Name: "Istanbul", Latitude: 41.0082, Longitude: 28.9784, Color: "#ff0000"}})
+
+	data, err := json.Marshal(fc)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "FeatureCollection", "features": [{
+		"type": "Feature", "id": 7,
+		"geometry": {"type": "Point", "coordinates": [28.9784, 41.0082]},
+		"properties": {"name": "Istanbul", "color": "#ff0000"}
+	}]}`, string(data))
+
+	data, err = json.Marshal(LocationsGeoJSON(nil))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "FeatureCollection", "features": []}`, string(data))
+}
+
+func TestRouteGeoJSON(t *testing.T) {
+	route := dto.RouteResponse{
+		Stops: []dto.RouteStopResponse{
+			{Sequence: 1, Location: model.Location{ID: 2, Name: "B", Latitude: 41, Longitude: 29}, LegDistance: 1, CumulativeDistance: 1},
+			{Sequence: 2, Location: model.Location{ID: 1, Name: "A", Latitude: 42, Longitude: 30}, LegDistance: 2, CumulativeDistance: 3},
+		},
+		Summary: dto.RouteSummary{TotalDistance: 3, StopCount: 2, Unit: dto.UnitKilometers},
+	}
+
+	fc := RouteGeoJSON(route, 40, 28)
+	require.Len(t, fc.Features, 3)
+	path := fc.Features[0]
+	assert.Equal(t, "LineString", path.Geometry.Type)
+	assert.Equal(t, [][]float64{{28, 40}, {29, 41}, {30, 42}}, path.Geometry.Coordinates, "the path starts at the origin")
+	assert.Equal(t, 3.0, path.Properties["total_distance"])
+
+	assert.Equal(t, uint(2), fc.Features[1].ID)
+	assert.Equal(t, 1, fc.Features[1].Properties["sequence"])
+	assert.Equal(t, 3.0, fc.Features[2].Properties["cumulative_distance"])
+
+	empty := RouteGeoJSON(dto.RouteResponse{}, 40, 28)
+	assert.Empty(t, empty.Features, "a route without stops has no LineString")
+
+	route.Start = &model.Location{ID: 9, Name: "Depot", Latitude: 39, Longitude: 27}
+	anchored := RouteGeoJSON(route, 0, 0)
+	require.Len(t, anchored.Features, 4)
+	assert.Equal(t, []float64{27, 39}, anchored.Features[0].Geometry.Coordinates.([][]float64)[0], "the path starts at the start location")
+	assert.Equal(t, uint(9), anchored.Features[1].ID)
+	assert.Equal(t, 0, anchored.Features[1].Properties["sequence"])
+}
+
+func TestGeoJSONDecoder(t *testing.T) {
+	input := `{
+		"name": "delivery points",
+		"type": "FeatureCollection",
+		"features": [
+			{"type": "Feature", "geometry": {"type": "Point", "coordinates": [28.9784, 41.0082, 40]}, "properties": {"name": "Istanbul", "color": "#ff0000"}},
+			{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[1, 2], [3, 4]]}, "properties": {}},
+			{"type": "Feature", "geometry": {"type": "Point", "coordinates": [1]}, "properties": {}},
+			{"type": "Feature", "geometry": {"type": "Point", "coordinates": [32.8597, 39.9334]}, "properties": {"title": "Ankara", "marker-color": "#00ff00"}},
+			{"type": "Feature", "geometry": {"type": "Point", "coordinates": [1, 2]}, "properties": {"name": 5}}
+		],
+		"bbox": [1, 2, 3, 4]
+	}`
+
+	d, err := NewGeoJSONDecoder(strings.NewReader(input))
+	require.NoError(t, err)
+	requests, recErrs := decodeAll(t, d)
+
+	assert.Equal(t, []dto.LocationRequest{
+		{Name: "Istanbul", Latitude: 41.0082, Longitude: 28.9784, Color: "#ff0000"},
+		{Name: "Ankara", Latitude: 39.9334, Longitude: 32.8597, Color: "#00ff00"},
+	}, requests)
+	require.Len(t, recErrs, 3)
+	assert.EqualError(t, recErrs[0], "record 1: geometry is not a Point")
+	assert.Equal(t, 2, recErrs[1].Index)
+	assert.EqualError(t, recErrs[2], `record 4: property "name" must be a string`)
+}
+
+func TestGeoJSONDecoder_InvalidDocuments(t *testing.T) {
+	_, err := NewGeoJSONDecoder(strings.NewReader(`{"type": "Feature", "features": []}`))
+	assert.EqualError(t, err, "GeoJSON must be a FeatureCollection")
+
+	_, err = NewGeoJSONDecoder(strings.NewReader(`{"type": "FeatureCollection"}`))
+	assert.EqualError(t, err, `GeoJSON FeatureCollection has no "features" array`)
+
+	_, err = NewGeoJSONDecoder(strings.NewReader(`[]`))
+	assert.ErrorContains(t, err, "expected")
+
+	d, err := NewGeoJSONDecoder(strings.NewReader(`{"type": "FeatureCollection", "features": [{"type": "Feature",`))
+	require.NoError(t, err)
+	_, err = d.Next()
+	var recErr *RecordError
+	assert.Error(t, err)
+	assert.NotErrorAs(t, err, &recErr, "malformed JSON ends decoding")
+}
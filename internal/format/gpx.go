@@ -0,0 +1,157 @@
+package format
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/yusufbulac/location-routing-service/internal/dto"
+)
+
+const gpxNamespace = "http://www.topografix.com/GPX/1/1"
+
+type gpxDocument struct {
+	XMLName  xml.Name    `xml:"gpx"`
+	Version  string      `xml:"version,attr"`
+	Creator  string      `xml:"creator,attr"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Metadata gpxMetadata `xml:"metadata"`
+	Route    gpxRoute    `xml:"rte"`
+}
+
+type gpxMetadata struct {
+	Name string `xml:"name"`
+	Desc string `xml:"desc,omitempty"`
+}
+
+type gpxRoute struct {
+	Name   string     `xml:"name"`
+	Points []gpxPoint `xml:"rtept"`
+}
+
+type gpxPoint struct {
+	Lat  string `xml:"lat,attr"`
+	Lon  string `xml:"lon,attr"`
+	Name string `xml:"name,omitempty"`
+	Desc string `xml:"desc,omitempty"`
+}
+
+// WriteRouteGPX writes a route as a GPX 1.1 document with one rtept per stop,
+// named after its location. A start location is the first rtept; a reference
+// point is not part of the route, as GPS units navigate from their current
+// position.
+func WriteRouteGPX(w io.Writer, route dto.RouteResponse) error {
+	doc := gpxDocument{
+		Version: "1.1",
+		Creator: "location-routing-service",
+		Xmlns:   gpxNamespace,
+		Metadata: gpxMetadata{
+			Name: "Route",
+			Desc: routeSummary(route),
+		},
+		Route: gpxRoute{Name: "Route", Points: make([]gpxPoint, 0, len(route.Stops)+1)},
+	}
+	if route.Start != nil {
+		doc.Route.Points = append(doc.Route.Points, gpxPoint{
+			Lat:  formatCoordinate(route.Start.Latitude),
+			Lon:  formatCoordinate(route.Start.Longitude),
+			Name: route.Start.Name,
+			Desc: "Start",
+		})
+	}
+	for _, stop := range route.Stops {
+		doc.Route.Points = append(doc.Route.Points, gpxPoint{
+			Lat:  formatCoordinate(stop.Location.Latitude),
+			Lon:  formatCoordinate(stop.Location.Longitude),
+			Name: stop.Location.Name,
+			Desc: stopSummary(stop, route.Summary.Unit),
+		})
+	}
+	return writeXML(w, doc)
+}
+
+// GPXDecoder reads location requests from the waypoints (wpt) and route
+// points (rtept) of a GPX file, one point at a time. Track points are
+// ignored. GPX has no colors, so requests carry none.
+type GPXDecoder struct {
+	dec      *xml.Decoder
+	position Position
+}
+
+func NewGPXDecoder(r io.Reader) *GPXDecoder {
+	return &GPXDecoder{dec: xml.NewDecoder(r), position: Position{Index: -1}}
+}
+
+func (d *GPXDecoder) Next() (dto.LocationRequest, error) {
+	for {
+		token, err := d.dec.Token()
+		if errors.Is(err, io.EOF) {
+			return dto.LocationRequest{}, io.EOF
+		}
+		if err != nil {
+			return dto.LocationRequest{}, fmt.Errorf("read GPX: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || (start.Name.Local != "wpt" && start.Name.Local != "rtept") {
+			continue
+		}
+
+		line, _ := d.dec.InputPos()
+		var point gpxPoint
+		if err := d.dec.DecodeElement(&point, &start); err != nil {
+			return dto.LocationRequest{}, fmt.Errorf("read GPX: %w", err)
+		}
+		d.position = Position{Index: d.position.Index + 1, Line: line}
+
+		lat, latErr := parseCoordinate("lat", point.Lat)
+		lng, lngErr := parseCoordinate("lon", point.Lon)
+		if err := errors.Join(latErr, lngErr); err != nil {
+			return dto.LocationRequest{}, &RecordError{Position: d.position, Err: err}
+		}
+		return dto.LocationRequest{Name: strings.TrimSpace(point.Name), Latitude: lat, Longitude: lng}, nil
+	}
+}
+
+func (d *GPXDecoder) Position() Position {
+	return d.position
+}
+
+func parseCoordinate(name, raw string) (float64, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, fmt.Errorf("%s is missing", name)
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %q is not a number", name, raw)
+	}
+	return v, nil
+}
+
+func formatCoordinate(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func routeSummary(route dto.RouteResponse) string {
+	return fmt.Sprintf("%d stops, %.2f %s", route.Summary.StopCount, route.Summary.TotalDistance, route.Summary.Unit)
+}
+
+func stopSummary(stop dto.RouteStopResponse, unit dto.DistanceUnit) string {
+	return fmt.Sprintf("Stop %d: leg %.2f %s, total %.2f %s", stop.Sequence, stop.LegDistance, unit, stop.CumulativeDistance, unit)
+}
+
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
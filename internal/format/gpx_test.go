@@ -0,0 +1,76 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yusufbulac/location-routing-service/internal/dto"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+)
+
+func sampleRoute() dto.RouteResponse {
+	return dto.RouteResponse{
+		Stops: []dto.RouteStopResponse{
+			{Sequence: 1, Location: model.Location{ID: 2, Name: "Depot & Co", Latitude: 41, Longitude: 29, Color: "#FF8800"}, LegDistance: 1, CumulativeDistance: 1},
+			{Sequence: 2, Location: model.Location{ID: 1, Name: "Shop", Latitude: 42.5, Longitude: 30.25, Color: "#ff8800"}, LegDistance: 2, CumulativeDistance: 3},
+		},
+		Summary: dto.RouteSummary{TotalDistance: 3, StopCount: 2, Unit: dto.UnitKilometers},
+	}
+}
+
+func TestWriteRouteGPX(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteRouteGPX(&buf, sampleRoute()))
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, `<?xml version="1.0" encoding="UTF-8"?>`))
+	assert.Contains(t, out, `<gpx version="1.1" creator="location-routing-service" xmlns="http://www.topografix.com/GPX/1/1">`)
+	assert.Contains(t, out, `<rtept lat="41" lon="29">`)
+	assert.Contains(t, out, `<name>Depot &amp; Co</name>`)
+	assert.Contains(t, out, `<desc>Stop 2: leg 2.00 km, total 3.00 km</desc>`)
+	assert.Equal(t, 2, strings.Count(out, "<rtept "), "the origin is not a route point")
+
+	requests, recErrs := decodeAll(t, NewGPXDecoder(&buf))
+	assert.Empty(t, recErrs)
+	assert.Equal(t, []dto.LocationRequest{
+		{Name: "Depot & Co", Latitude: 41, Longitude: 29},
+		{Name: "Shop", Latitude: 42.5, Longitude: 30.25},
+	}, requests, "an exported route imports back")
+
+	route := sampleRoute()
+	route.Start = &model.Location{Name: "Depot", Latitude: 39, Longitude: 27}
+	buf.Reset()
+	require.NoError(t, WriteRouteGPX(&buf, route))
+	assert.Equal(t, 3, strings.Count(buf.String(), "<rtept "))
+	assert.Contains(t, buf.String(), `<rtept lat="39" lon="27">`, "a start location is the first route point")
+}
+
+func TestGPXDecoder(t *testing.T) {
+	input := `<?xml version="1.0"?>
+<gpx version="1.1" xmlns="http://www.topografix.com/GPX/1/1">
+  <wpt lat="41.0082" lon="28.9784"><name> Istanbul </name><ele>40</ele></wpt>
+  <wpt lat="north" lon="28"><name>Broken</name></wpt>
+  <trk><trkseg><trkpt lat="1" lon="2"/></trkseg></trk>
+  <rte>
+    <rtept lon="32.8597"><name>No latitude</name></rtept>
+    <rtept lat="39.9334" lon="32.8597"><name>Ankara</name></rtept>
+  </rte>
+</gpx>`
+
+	requests, recErrs := decodeAll(t, NewGPXDecoder(strings.NewReader(input)))
+	assert.Equal(t, []dto.LocationRequest{
+		{Name: "Istanbul", Latitude: 41.0082, Longitude: 28.9784},
+		{Name: "Ankara", Latitude: 39.9334, Longitude: 32.8597},
+	}, requests)
+	require.Len(t, recErrs, 2)
+	assert.EqualError(t, recErrs[0], `line 4: lat: "north" is not a number`)
+	assert.EqualError(t, recErrs[1], "line 7: lat is missing")
+
+	_, err := NewGPXDecoder(strings.NewReader(`<gpx><wpt lat="1"`)).Next()
+	var recErr *RecordError
+	assert.Error(t, err)
+	assert.NotErrorAs(t, err, &recErr, "malformed XML ends decoding")
+}
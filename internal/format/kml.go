@@ -0,0 +1,279 @@
+package format
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/yusufbulac/location-routing-service/internal/dto"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+)
+
+const kmlNamespace = "http://www.opengis.net/kml/2.2"
+
+// routeLineColor is the KML color (aabbggrr) of the route path.
+const routeLineColor = "ffff6600"
+
+type kmlDocument struct {
+	XMLName  xml.Name    `xml:"kml"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Document kmlContents `xml:"Document"`
+}
+
+type kmlContents struct {
+	Name        string         `xml:"name"`
+	Description string         `xml:"description,omitempty"`
+	Styles      []kmlStyle     `xml:"Style"`
+	Placemarks  []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlStyle struct {
+	ID        string         `xml:"id,attr,omitempty"`
+	IconStyle *kmlColorStyle `xml:"IconStyle,omitempty"`
+	LineStyle *kmlLineStyle  `xml:"LineStyle,omitempty"`
+}
+
+type kmlColorStyle struct {
+	Color string `xml:"color,omitempty"`
+}
+
+type kmlLineStyle struct {
+	Color string  `xml:"color,omitempty"`
+	Width float64 `xml:"width,omitempty"`
+}
+
+type kmlStyleMap struct {
+	ID    string `xml:"id,attr"`
+	Pairs []struct {
+		Key      string `xml:"key"`
+		StyleURL string `xml:"styleUrl"`
+	} `xml:"Pair"`
+}
+
+type kmlPlacemark struct {
+	Name        string         `xml:"name,omitempty"`
+	Description string         `xml:"description,omitempty"`
+	StyleURL    string         `xml:"styleUrl,omitempty"`
+	Style       *kmlStyle      `xml:"Style,omitempty"`
+	Point       *kmlPoint      `xml:"Point,omitempty"`
+	LineString  *kmlLineString `xml:"LineString,omitempty"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlLineString struct {
+	Tessellate  int    `xml:"tessellate,omitempty"`
+	Coordinates string `xml:"coordinates"`
+}
+
+// WriteRouteKML writes a route from (originLat, originLng) as a KML document:
+// a Placemark per stop in visiting order, styled with the location's color,
+// followed by a path from the origin through every stop. A route anchored at a
+// start location begins there instead, with its own Placemark before the
+// stops. A route without stops has no path.
+func WriteRouteKML(w io.Writer, route dto.RouteResponse, originLat, originLng float64) error {
+	doc := kmlDocument{
+		Xmlns: kmlNamespace,
+		Document: kmlContents{
+			Name:        "Route",
+			Description: routeSummary(route),
+			Placemarks:  make([]kmlPlacemark, 0, len(route.Stops)+2),
+		},
+	}
+
+	styles := make(map[string]string)
+	addPlacemark := func(loc model.Location, description string) {
+		placemark := kmlPlacemark{
+			Name:        loc.Name,
+			Description: description,
+			Point:       &kmlPoint{Coordinates: kmlCoordinate(loc.Latitude, loc.Longitude)},
+		}
+		if color, err := KMLColor(loc.Color); err == nil {
+			id, ok := styles[color]
+			if !ok {
+				id = fmt.Sprintf("color-%d", len(styles)+1)
+				styles[color] = id
+				doc.Document.Styles = append(doc.Document.Styles, kmlStyle{ID: id, IconStyle: &kmlColorStyle{Color: color}})
+			}
+			placemark.StyleURL = "#" + id
+		}
+		doc.Document.Placemarks = append(doc.Document.Placemarks, placemark)
+	}
+	if route.Start != nil {
+		addPlacemark(*route.Start, "Start")
+	}
+	for _, stop := range route.Stops {
+		addPlacemark(stop.Location, stopSummary(stop, route.Summary.Unit))
+	}
+
+	if len(route.Stops) > 0 {
+		originLat, originLng = routeOrigin(route, originLat, originLng)
+		path := make([]string, 0, len(route.Stops)+1)
+		path = append(path, kmlCoordinate(originLat, originLng))
+		for _, stop := range route.Stops {
+			path = append(path, kmlCoordinate(stop.Location.Latitude, stop.Location.Longitude))
+		}
+		doc.Document.Placemarks = append(doc.Document.Placemarks, kmlPlacemark{
+			Name:       "Route",
+			Style:      &kmlStyle{LineStyle: &kmlLineStyle{Color: routeLineColor, Width: 3}},
+			LineString: &kmlLineString{Tessellate: 1, Coordinates: strings.Join(path, " ")},
+		})
+	}
+	return writeXML(w, doc)
+}
+
+func kmlCoordinate(lat, lng float64) string {
+	return formatCoordinate(lng) + "," + formatCoordinate(lat)
+}
+
+// KMLColor converts a "#rrggbb" color to the opaque "aabbggrr" form used by KML.
+func KMLColor(hex string) (string, error) {
+	if len(hex) != 7 || hex[0] != '#' {
+		return "", fmt.Errorf("%q is not a #rrggbb color", hex)
+	}
+	if _, err := strconv.ParseUint(hex[1:], 16, 32); err != nil {
+		return "", fmt.Errorf("%q is not a #rrggbb color", hex)
+	}
+	rr, gg, bb := hex[1:3], hex[3:5], hex[5:7]
+	return strings.ToLower("ff" + bb + gg + rr), nil
+}
+
+// HexColor converts a KML "aabbggrr" color to "#rrggbb", dropping the alpha.
+func HexColor(kml string) (string, error) {
+	kml = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(kml), "#"))
+	if len(kml) != 8 {
+		return "", fmt.Errorf("%q is not an aabbggrr color", kml)
+	}
+	if _, err := strconv.ParseUint(kml, 16, 32); err != nil {
+		return "", fmt.Errorf("%q is not an aabbggrr color", kml)
+	}
+	bb, gg, rr := kml[2:4], kml[4:6], kml[6:8]
+	return strings.ToLower("#" + rr + gg + bb), nil
+}
+
+// KMLDecoder reads location requests from the Point placemarks of a KML file,
+// one placemark at a time; placemarks with other geometries, such as paths,
+// are skipped. The color comes from the placemark's icon style, either inline
+// or shared through styleUrl. Shared styles must be declared before the
+// placemarks using them, as KML files usually do.
+type KMLDecoder struct {
+	dec       *xml.Decoder
+	styles    map[string]string
+	styleMaps map[string]string
+	position  Position
+}
+
+func NewKMLDecoder(r io.Reader) *KMLDecoder {
+	return &KMLDecoder{
+		dec:       xml.NewDecoder(r),
+		styles:    make(map[string]string),
+		styleMaps: make(map[string]string),
+		position:  Position{Index: -1},
+	}
+}
+
+func (d *KMLDecoder) Next() (dto.LocationRequest, error) {
+	for {
+		token, err := d.dec.Token()
+		if errors.Is(err, io.EOF) {
+			return dto.LocationRequest{}, io.EOF
+		}
+		if err != nil {
+			return dto.LocationRequest{}, fmt.Errorf("read KML: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "Style":
+			var style kmlStyle
+			if err := d.dec.DecodeElement(&style, &start); err != nil {
+				return dto.LocationRequest{}, fmt.Errorf("read KML: %w", err)
+			}
+			if style.ID != "" && style.IconStyle != nil {
+				d.styles[style.ID] = style.IconStyle.Color
+			}
+		case "StyleMap":
+			var styleMap kmlStyleMap
+			if err := d.dec.DecodeElement(&styleMap, &start); err != nil {
+				return dto.LocationRequest{}, fmt.Errorf("read KML: %w", err)
+			}
+			for _, pair := range styleMap.Pairs {
+				if strings.TrimSpace(pair.Key) == "normal" {
+					d.styleMaps[styleMap.ID] = styleID(pair.StyleURL)
+				}
+			}
+		case "Placemark":
+			line, _ := d.dec.InputPos()
+			var placemark kmlPlacemark
+			if err := d.dec.DecodeElement(&placemark, &start); err != nil {
+				return dto.LocationRequest{}, fmt.Errorf("read KML: %w", err)
+			}
+			if placemark.Point == nil {
+				continue
+			}
+			d.position = Position{Index: d.position.Index + 1, Line: line}
+
+			req, err := d.decode(placemark)
+			if err != nil {
+				return dto.LocationRequest{}, &RecordError{Position: d.position, Err: err}
+			}
+			return req, nil
+		}
+	}
+}
+
+func (d *KMLDecoder) Position() Position {
+	return d.position
+}
+
+func (d *KMLDecoder) decode(placemark kmlPlacemark) (dto.LocationRequest, error) {
+	// coordinates are "longitude,latitude[,altitude]"
+	parts := strings.Split(strings.TrimSpace(placemark.Point.Coordinates), ",")
+	if len(parts) < 2 {
+		return dto.LocationRequest{}, errors.New("Point coordinates must be longitude,latitude")
+	}
+	lng, lngErr := parseCoordinate("longitude", parts[0])
+	lat, latErr := parseCoordinate("latitude", parts[1])
+	if err := errors.Join(lngErr, latErr); err != nil {
+		return dto.LocationRequest{}, err
+	}
+
+	req := dto.LocationRequest{Name: strings.TrimSpace(placemark.Name), Latitude: lat, Longitude: lng}
+	if color := d.color(placemark); color != "" {
+		hex, err := HexColor(color)
+		if err != nil {
+			return dto.LocationRequest{}, fmt.Errorf("color: %w", err)
+		}
+		req.Color = hex
+	}
+	return req, nil
+}
+
+// color returns the KML icon color of a placemark, or "" if it has none.
+func (d *KMLDecoder) color(placemark kmlPlacemark) string {
+	if placemark.Style != nil && placemark.Style.IconStyle != nil && placemark.Style.IconStyle.Color != "" {
+		return placemark.Style.IconStyle.Color
+	}
+	id := styleID(placemark.StyleURL)
+	if normal, ok := d.styleMaps[id]; ok {
+		id = normal
+	}
+	return d.styles[id]
+}
+
+// styleID returns the ID of a local style reference such as "#red".
+func styleID(url string) string {
+	url = strings.TrimSpace(url)
+	if i := strings.LastIndexByte(url, '#'); i >= 0 {
+		return url[i+1:]
+	}
+	return url
+}
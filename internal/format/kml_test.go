@@ -0,0 +1,87 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yusufbulac/location-routing-service/internal/dto"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+)
+
+func TestWriteRouteKML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteRouteKML(&buf, sampleRoute(), 40, 28))
+	out := buf.String()
+
+	assert.Contains(t, out, `<kml xmlns="http://www.opengis.net/kml/2.2">`)
+	assert.Equal(t, 1, strings.Count(out, "<IconStyle>"), "stops of the same color share a style")
+	assert.Contains(t, out, `<color>ff0088ff</color>`)
+	assert.Equal(t, 2, strings.Count(out, `<styleUrl>#color-1</styleUrl>`))
+	assert.Contains(t, out, `<coordinates>29,41</coordinates>`)
+	assert.Contains(t, out, `<coordinates>28,40 29,41 30.25,42.5</coordinates>`, "the path starts at the origin")
+
+	requests, recErrs := decodeAll(t, NewKMLDecoder(&buf))
+	assert.Empty(t, recErrs)
+	assert.Equal(t, []dto.LocationRequest{
+		{Name: "Depot & Co", Latitude: 41, Longitude: 29, Color: "#ff8800"},
+		{Name: "Shop", Latitude: 42.5, Longitude: 30.25, Color: "#ff8800"},
+	}, requests, "an exported route imports back without its path")
+
+	route := sampleRoute()
+	route.Start = &model.Location{Name: "Depot", Latitude: 39, Longitude: 27, Color: "#0000ff"}
+	buf.Reset()
+	require.NoError(t, WriteRouteKML(&buf, route, 0, 0))
+	assert.Contains(t, buf.String(), `<name>Depot</name>`)
+	assert.Contains(t, buf.String(), `<coordinates>27,39 29,41 30.25,42.5</coordinates>`, "the path starts at the start location")
+
+	buf.Reset()
+	require.NoError(t, WriteRouteKML(&buf, dto.RouteResponse{}, 40, 28))
+	assert.NotContains(t, buf.String(), "<LineString>", "a route without stops has no path")
+}
+
+func TestKMLDecoder(t *testing.T) {
+	input := `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+<Document>
+  <Style id="red"><IconStyle><color>ff0000ff</color></IconStyle></Style>
+  <StyleMap id="red-map"><Pair><key>normal</key><styleUrl>#red</styleUrl></Pair><Pair><key>highlight</key><styleUrl>#blue</styleUrl></Pair></StyleMap>
+  <Folder>
+    <Placemark><name>Istanbul</name><styleUrl>#red-map</styleUrl><Point><coordinates> 28.9784,41.0082,0 </coordinates></Point></Placemark>
+    <Placemark><name>Ankara</name><Style><IconStyle><color>80ff0000</color></IconStyle></Style><Point><coordinates>32.8597,39.9334</coordinates></Point></Placemark>
+  </Folder>
+  <Placemark><name>Path</name><LineString><coordinates>1,2 3,4</coordinates></LineString></Placemark>
+  <Placemark><name>Plain</name><Point><coordinates>1,2</coordinates></Point></Placemark>
+  <Placemark><name>Flat</name><Point><coordinates>1</coordinates></Point></Placemark>
+  <Placemark><name>Odd color</name><Style><IconStyle><color>red</color></IconStyle></Style><Point><coordinates>1,2</coordinates></Point></Placemark>
+</Document>
+</kml>`
+
+	requests, recErrs := decodeAll(t, NewKMLDecoder(strings.NewReader(input)))
+	assert.Equal(t, []dto.LocationRequest{
+		{Name: "Istanbul", Latitude: 41.0082, Longitude: 28.9784, Color: "#ff0000"},
+		{Name: "Ankara", Latitude: 39.9334, Longitude: 32.8597, Color: "#0000ff"},
+		{Name: "Plain", Latitude: 2, Longitude: 1},
+	}, requests)
+	require.Len(t, recErrs, 2)
+	assert.EqualError(t, recErrs[0], "line 12: Point coordinates must be longitude,latitude")
+	assert.Equal(t, 4, recErrs[1].Index, "skipped placemarks are not counted")
+	assert.ErrorContains(t, recErrs[1], "color:")
+}
+
+func TestKMLColor(t *testing.T) {
+	color, err := KMLColor("#12AB34")
+	require.NoError(t, err)
+	assert.Equal(t, "ff34ab12", color)
+
+	hex, err := HexColor(color)
+	require.NoError(t, err)
+	assert.Equal(t, "#12ab34", hex)
+
+	_, err = KMLColor("12ab34")
+	assert.Error(t, err)
+	_, err = HexColor("ff34ab1z")
+	assert.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// BoundingBox is a latitude/longitude rectangle in degrees. When MinLng is
+// greater than MaxLng the box crosses the antimeridian and covers the
+// longitudes from MinLng to 180 and from -180 to MaxLng.
+type BoundingBox struct {
+	MinLat float64
+	MinLng float64
+	MaxLat float64
+	MaxLng float64
+}
+
+// CrossesAntimeridian reports whether the box wraps around longitude ±180.
+func (b BoundingBox) CrossesAntimeridian() bool {
+	return b.MinLng > b.MaxLng
+}
+
+// Contains reports whether the point lies inside the box, edges included.
+func (b BoundingBox) Contains(lat, lng float64) bool {
+	if lat < b.MinLat || lat > b.MaxLat {
+		return false
+	}
+	if b.CrossesAntimeridian() {
+		return lng >= b.MinLng || lng <= b.MaxLng
+	}
+	return lng >= b.MinLng && lng <= b.MaxLng
+}
+
+// BoundingBoxAround returns the smallest box containing every point within
+// radiusKm of the given centre. Boxes reaching a pole span all longitudes.
+func BoundingBoxAround(lat, lng, radiusKm float64) BoundingBox {
+	angular := radiusKm / EarthRadiusKm
+	latDelta := angular * 180 / math.Pi
+
+	minLat, maxLat := lat-latDelta, lat+latDelta
+	if minLat <= -90 || maxLat >= 90 || angular >= math.Pi/2 {
+		return BoundingBox{
+			MinLat: math.Max(minLat, -90),
+			MinLng: -180,
+			MaxLat: math.Min(maxLat, 90),
+			MaxLng: 180,
+		}
+	}
+
+	ratio := math.Sin(angular) / math.Cos(lat*math.Pi/180)
+	if ratio >= 1 {
+		return BoundingBox{MinLat: minLat, MinLng: -180, MaxLat: maxLat, MaxLng: 180}
+	}
+	lngDelta := math.Asin(ratio) * 180 / math.Pi
+
+	minLng, maxLng := lng-lngDelta, lng+lngDelta
+	if minLng < -180 {
+		minLng += 360
+	}
+	if maxLng > 180 {
+		maxLng -= 360
+	}
+	return BoundingBox{MinLat: minLat, MinLng: minLng, MaxLat: maxLat, MaxLng: maxLng}
+}
+
+// ParseBoundingBox parses a "minLng,minLat,maxLng,maxLat" string. A minLng
+// greater than maxLng describes a box crossing the antimeridian.
+func ParseBoundingBox(s string) (BoundingBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return BoundingBox{}, errors.New("bbox must have four comma-separated values: minLng,minLat,maxLng,maxLat")
+	}
+
+	values := make([]float64, 4)
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BoundingBox{}, fmt.Errorf("bbox value %q is not a number", part)
+		}
+		values[i] = v
+	}
+
+	box := BoundingBox{MinLng: values[0], MinLat: values[1], MaxLng: values[2], MaxLat: values[3]}
+	if box.MinLng < -180 || box.MinLng > 180 || box.MaxLng < -180 || box.MaxLng > 180 {
+		return BoundingBox{}, errors.New("bbox longitudes must be between -180 and 180")
+	}
+	if box.MinLat < -90 || box.MaxLat > 90 || box.MinLat > box.MaxLat {
+		return BoundingBox{}, errors.New("bbox latitudes must be between -90 and 90 with minLat <= maxLat")
+	}
+	return box, nil
+}
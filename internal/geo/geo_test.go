@@ -0,0 +1,100 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHaversine(t *testing.T) {
+	assert.Zero(t, Haversine(41, 29, 41, 29))
+	// One degree of latitude is roughly 111.19 km.
+	assert.InDelta(t, 111.19, Haversine(0, 0, 1, 0), 0.01)
+	assert.InDelta(t, Haversine(0, 179.5, 0, -179.5), Haversine(0, 0, 0, 1), 1e-9)
+}
+
+func TestInitialBearing(t *testing.T) {
+	assert.InDelta(t, 0, InitialBearing(0, 0, 1, 0), 1e-9)
+	assert.InDelta(t, 90, InitialBearing(0, 0, 0, 1), 1e-9)
+	assert.InDelta(t, 180, InitialBearing(1, 0, 0, 0), 1e-9)
+	assert.InDelta(t, 270, InitialBearing(0, 1, 0, 0), 1e-9)
+}
+
+func TestBoundingBoxAround(t *testing.T) {
+	box := BoundingBoxAround(41, 29, 10)
+
+	assert.False(t, box.CrossesAntimeridian())
+	assert.True(t, box.Contains(41, 29))
+	assert.True(t, box.Contains(41.08, 29))
+	assert.False(t, box.Contains(41.1, 29))
+	assert.False(t, box.Contains(41, 29.2))
+}
+
+func TestBoundingBoxAround_Antimeridian(t *testing.T) {
+	box := BoundingBoxAround(0, 179.95, 20)
+
+	assert.True(t, box.CrossesAntimeridian())
+	assert.True(t, box.Contains(0, 179.99))
+	assert.True(t, box.Contains(0, -179.95))
+	assert.False(t, box.Contains(0, -179.5))
+	assert.False(t, box.Contains(0, 0))
+}
+
+func TestBoundingBoxAround_Pole(t *testing.T) {
+	box := BoundingBoxAround(89.95, 10, 20)
+
+	assert.Equal(t, 90.0, box.MaxLat)
+	assert.Equal(t, -180.0, box.MinLng)
+	assert.Equal(t, 180.0, box.MaxLng)
+	assert.True(t, box.Contains(89.99, -170))
+}
+
+func TestParseBoundingBox(t *testing.T) {
+	box, err := ParseBoundingBox("28.5, 40.8,29.5,41.3")
+	assert.NoError(t, err)
+	assert.Equal(t, BoundingBox{MinLat: 40.8, MinLng: 28.5, MaxLat: 41.3, MaxLng: 29.5}, box)
+	assert.False(t, box.CrossesAntimeridian())
+
+	box, err = ParseBoundingBox("170,-20,-170,-10")
+	assert.NoError(t, err)
+	assert.True(t, box.CrossesAntimeridian())
+	assert.True(t, box.Contains(-15, 175))
+	assert.True(t, box.Contains(-15, -175))
+	assert.False(t, box.Contains(-15, 0))
+
+	for _, invalid := range []string{"", "1,2,3", "a,b,c,d", "0,10,1,5", "0,-91,1,5", "-181,0,1,1"} {
+		_, err := ParseBoundingBox(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	assert.Equal(t, []int{1, 2}, Paginate(items, 2, 0))
+	assert.Equal(t, []int{4, 5}, Paginate(items, 10, 3))
+	assert.Equal(t, []int{}, Paginate(items, 2, 5))
+}
+
+func TestSearchNearest(t *testing.T) {
+	var radii []float64
+	nearest, err := SearchNearest(2, func(radiusKm float64) ([]float64, error) {
+		radii = append(radii, radiusKm)
+		if radiusKm < 80 {
+			return []float64{1}, nil
+		}
+		return []float64{1, 2, 3}, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 2}, nearest)
+	assert.Equal(t, []float64{5, 20, 80}, radii)
+
+	var last float64
+	nearest, err = SearchNearest(3, func(radiusKm float64) ([]float64, error) {
+		last = radiusKm
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, nearest)
+	assert.InDelta(t, math.Pi*EarthRadiusKm, last, 1e-9, "the search stops once the whole Earth is covered")
+}
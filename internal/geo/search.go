@@ -0,0 +1,42 @@
+package geo
+
+import "math"
+
+const (
+	// NearestSearchStartKm is the radius of the first probe of SearchNearest.
+	NearestSearchStartKm = 5
+	// NearestSearchGrowth is the factor the probe radius grows by between probes.
+	NearestSearchGrowth = 4
+)
+
+// SearchNearest finds the k items closest to a point by calling probe with
+// growing radii until it returns at least k items, which guarantees no closer
+// item lies outside the probed radius, or the radius covers the whole Earth.
+// probe returns the items within the radius ordered by distance; the first k
+// of the last probe are returned.
+func SearchNearest[T any](k int, probe func(radiusKm float64) ([]T, error)) ([]T, error) {
+	maxRadius := math.Pi * EarthRadiusKm
+	for radius := float64(NearestSearchStartKm); ; radius *= NearestSearchGrowth {
+		radius = math.Min(radius, maxRadius)
+
+		items, err := probe(radius)
+		if err != nil {
+			return nil, err
+		}
+		if len(items) >= k || radius >= maxRadius {
+			return Paginate(items, k, 0), nil
+		}
+	}
+}
+
+// Paginate returns the page of items starting at offset with at most limit items.
+func Paginate[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+	if limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
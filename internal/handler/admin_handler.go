@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yusufbulac/location-routing-service/internal/dto"
+	"github.com/yusufbulac/location-routing-service/internal/logger"
+	"github.com/yusufbulac/location-routing-service/internal/service"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type AdminHandler struct {
+	service   service.LocationService
+	retention time.Duration
+}
+
+// NewAdminHandler creates the handler for administrative location endpoints.
+// retention is the minimum and default age of the soft-deleted locations a
+// purge removes.
+func NewAdminHandler(s service.LocationService, retention time.Duration) *AdminHandler {
+	return &AdminHandler{service: s, retention: retention}
+}
+
+// RestoreLocation godoc
+// @Summary Restore a deleted location
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param id path int true "Location ID"
+// @Success 200 {object} model.Location
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/admin/locations/{id}/restore [post]
+func (h *AdminHandler) RestoreLocation(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Warn("Invalid ID parameter", zap.String("id", idParam))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid ID",
+		})
+		return
+	}
+
+	location, err := h.service.RestoreLocation(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn("Deleted location not found", zap.Int("id", id))
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Message: "Deleted location not found",
+			})
+			return
+		}
+		logger.Error("Could not restore location", zap.Error(err))
+		c.JSON(serverErrorStatus(err), dto.ErrorResponse{
+			Message: "Could not restore location",
+		})
+		return
+	}
+
+	logger.Info("Location restored", zap.Int("id", id))
+	c.JSON(http.StatusOK, location)
+}
+
+// PurgeDeletedLocations godoc
+// @Summary Permanently remove soft-deleted locations
+// @Description Removes locations that were soft-deleted longer ago than older_than, which defaults to the configured retention and may not be shorter than it
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param older_than query string false "Minimum age of deleted rows, e.g. 720h"
+// @Success 200 {object} dto.PurgeResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/admin/locations/deleted [delete]
+func (h *AdminHandler) PurgeDeletedLocations(c *gin.Context) {
+	olderThan := h.retention
+	if param := c.Query("older_than"); param != "" {
+		parsed, err := time.ParseDuration(param)
+		if err != nil {
+			logger.Warn("Invalid older_than parameter", zap.String("older_than", param))
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Message: "Invalid older_than",
+			})
+			return
+		}
+		if parsed < h.retention {
+			logger.Warn("older_than below the retention", zap.String("older_than", param))
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Message: "older_than below the retention",
+				Details: fmt.Sprintf("older_than must be at least %s", h.retention),
+			})
+			return
+		}
+		olderThan = parsed
+	}
+
+	purged, err := h.service.PurgeDeletedLocations(c.Request.Context(), olderThan)
+	if err != nil {
+		logger.Error("Could not purge deleted locations", zap.Error(err))
+		c.JSON(serverErrorStatus(err), dto.ErrorResponse{
+			Message: "Could not purge deleted locations",
+		})
+		return
+	}
+
+	logger.Info("Purged deleted locations", zap.Int64("count", purged), zap.Duration("older_than", olderThan))
+	c.JSON(http.StatusOK, dto.PurgeResponse{Purged: purged, OlderThan: olderThan.String()})
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yusufbulac/location-routing-service/internal/cache"
+	"github.com/yusufbulac/location-routing-service/internal/dto"
+)
+
+type HealthHandler struct {
+	cache cache.Cache
+}
+
+func NewHealthHandler(c cache.Cache) *HealthHandler {
+	return &HealthHandler{cache: c}
+}
+
+// Health godoc
+// @Summary Service health
+// @Description Reports "ok", or "degraded" while the cache is unavailable; the service keeps serving either way
+// @Tags health
+// @Produce json
+// @Success 200 {object} dto.HealthResponse
+// @Router /health [get]
+func (h *HealthHandler) Health(c *gin.Context) {
+	status := h.cache.Status()
+
+	response := dto.HealthResponse{
+		Status: "ok",
+		Cache: dto.CacheHealth{
+			Driver:    status.Driver,
+			Available: status.Available,
+			Circuit:   status.Circuit,
+			LastError: status.LastError,
+		},
+	}
+	if !status.Available {
+		response.Status = "degraded"
+	}
+
+	c.JSON(http.StatusOK, response)
+}
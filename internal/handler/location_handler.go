@@ -1,10 +1,17 @@
 package handler
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"github.com/yusufbulac/location-routing-service/internal/dto"
+	"github.com/yusufbulac/location-routing-service/internal/format"
+	"github.com/yusufbulac/location-routing-service/internal/geo"
 	"github.com/yusufbulac/location-routing-service/internal/logger"
 	"github.com/yusufbulac/location-routing-service/internal/validation"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -13,6 +20,18 @@ import (
 	"github.com/yusufbulac/location-routing-service/internal/service"
 )
 
+const (
+	// maxNearestK caps the number of locations a k-nearest query may request.
+	maxNearestK = 100
+	// maxNearbyRadiusKm caps the radius of a radius search, which bounds the
+	// rows its bounding-box prefilter can match.
+	maxNearbyRadiusKm = 500
+	// maxBatchSize caps the number of items of a batch create request.
+	maxBatchSize = 5000
+	// maxRouteLocations caps the number of location IDs of a route request.
+	maxRouteLocations = 5000
+)
+
 type LocationHandler struct {
 	service service.LocationService
 }
@@ -57,9 +76,9 @@ func (h *LocationHandler) CreateLocation(c *gin.Context) {
 		Color:     req.Color,
 	}
 
-	if err := h.service.CreateLocation(&location); err != nil {
+	if err := h.service.CreateLocation(c.Request.Context(), &location); err != nil {
 		logger.Error("Could not create location", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+		c.JSON(serverErrorStatus(err), dto.ErrorResponse{
 			Message: "Could not create location",
 		})
 		return
@@ -69,40 +88,255 @@ func (h *LocationHandler) CreateLocation(c *gin.Context) {
 	c.JSON(http.StatusCreated, location)
 }
 
+// CreateLocationsBatch godoc
+// @Summary Add several locations at once
+// @Description Validates every item and stores the valid ones in a single transaction. In atomic mode (the default) nothing is stored if any item is invalid; in best_effort mode the valid items are stored. Invalid items are reported by their index.
+// @Tags locations
+// @Accept json
+// @Produce json
+// @Param batch body dto.BatchLocationRequest true "Locations and mode (atomic or best_effort)"
+// @Success 201 {object} dto.BatchLocationResponse
+// @Failure 400 {object} dto.BatchLocationResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/locations/batch [post]
+func (h *LocationHandler) CreateLocationsBatch(c *gin.Context) {
+	var req dto.BatchLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn("Invalid JSON received", zap.Error(err))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid JSON",
+		})
+		return
+	}
+
+	if err := validation.Validator.Struct(req); err != nil {
+		logger.Warn("Validation failed", zap.Error(err))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Validation failed",
+			Details: validation.FormatValidationError(err),
+		})
+		return
+	}
+
+	if len(req.Items) > maxBatchSize {
+		logger.Warn("Batch too large", zap.Int("items", len(req.Items)))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Batch too large",
+			Details: "at most " + strconv.Itoa(maxBatchSize) + " items per request",
+		})
+		return
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = dto.BatchModeAtomic
+	}
+
+	response := dto.BatchLocationResponse{
+		Mode:    mode,
+		Created: []dto.BatchCreatedLocation{},
+		Errors:  []dto.BatchItemError{},
+	}
+	locations := make([]model.Location, 0, len(req.Items))
+	indexes := make([]int, 0, len(req.Items))
+	for i, item := range req.Items {
+		if err := validation.Validator.Struct(item); err != nil {
+			response.Errors = append(response.Errors, dto.BatchItemError{
+				Index:   i,
+				Details: validation.FormatValidationError(err),
+			})
+			continue
+		}
+		locations = append(locations, model.Location{
+			Name:      item.Name,
+			Latitude:  item.Latitude,
+			Longitude: item.Longitude,
+			Color:     item.Color,
+		})
+		indexes = append(indexes, i)
+	}
+
+	if len(locations) == 0 || (mode == dto.BatchModeAtomic && len(response.Errors) > 0) {
+		logger.Warn("Batch validation failed", zap.String("mode", mode), zap.Int("invalid", len(response.Errors)))
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if err := h.service.CreateLocations(c.Request.Context(), locations); err != nil {
+		logger.Error("Could not create locations", zap.Error(err))
+		c.JSON(serverErrorStatus(err), dto.ErrorResponse{
+			Message: "Could not create locations",
+		})
+		return
+	}
+
+	for i, location := range locations {
+		response.Created = append(response.Created, dto.BatchCreatedLocation{Index: indexes[i], Location: location})
+	}
+
+	logger.Info("Locations created in batch", zap.String("mode", mode), zap.Int("created", len(response.Created)), zap.Int("invalid", len(response.Errors)))
+	c.JSON(http.StatusCreated, response)
+}
+
 // GetAllLocations godoc
 // @Summary List all locations
+// @Description Returns a GeoJSON FeatureCollection of Points when the request accepts application/geo+json
 // @Tags locations
 // @Produce json
+// @Produce application/geo+json
 // @Param limit query int false "Limit"
 // @Param offset query int false "Offset"
+// @Param bbox query string false "Viewport filter: minLng,minLat,maxLng,maxLat (minLng > maxLng crosses the antimeridian)"
 // @Success 200 {array} model.Location
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /api/v1/locations [get]
 func (h *LocationHandler) GetAllLocations(c *gin.Context) {
-	limitParam := c.DefaultQuery("limit", "10")
-	offsetParam := c.DefaultQuery("offset", "0")
-
-	limit, err1 := strconv.Atoi(limitParam)
-	offset, err2 := strconv.Atoi(offsetParam)
-	if err1 != nil || err2 != nil || limit < 1 || offset < 0 {
-		logger.Warn("Invalid pagination parameters", zap.String("limit", limitParam), zap.String("offset", offsetParam))
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Message: "Invalid pagination parameters",
-		})
+	limit, offset, ok := parsePagination(c)
+	if !ok {
 		return
 	}
 
-	locations, err := h.service.GetPaginatedLocations(limit, offset)
+	var locations []model.Location
+	var err error
+	if bboxParam := c.Query("bbox"); bboxParam != "" {
+		box, perr := geo.ParseBoundingBox(bboxParam)
+		if perr != nil {
+			logger.Warn("Invalid bbox parameter", zap.String("bbox", bboxParam), zap.Error(perr))
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Message: "Invalid bbox",
+				Details: perr.Error(),
+			})
+			return
+		}
+		locations, err = h.service.GetPaginatedLocationsInBox(c.Request.Context(), box, limit, offset)
+	} else {
+		locations, err = h.service.GetPaginatedLocations(c.Request.Context(), limit, offset)
+	}
 	if err != nil {
 		logger.Error("Failed to fetch paginated locations", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+		c.JSON(serverErrorStatus(err), dto.ErrorResponse{
 			Message: "Could not fetch locations",
 		})
 		return
 	}
 
 	logger.Info("Fetched paginated locations", zap.Int("count", len(locations)))
+	if wantsGeoJSON(c) {
+		renderGeoJSON(c, format.LocationsGeoJSON(locations))
+		return
+	}
+	c.JSON(http.StatusOK, locations)
+}
+
+// GetNearbyLocations godoc
+// @Summary List locations within a radius
+// @Description Returns locations within radius kilometres (at most 500) of the given point, ordered by distance
+// @Tags locations
+// @Produce json
+// @Param lat query number true "Latitude"
+// @Param lng query number true "Longitude"
+// @Param radius query number true "Radius in kilometres" maximum(500)
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {array} model.NearbyLocation
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/locations/nearby [get]
+func (h *LocationHandler) GetNearbyLocations(c *gin.Context) {
+	latParam := c.Query("lat")
+	lngParam := c.Query("lng")
+	radiusParam := c.Query("radius")
+
+	lat, err1 := strconv.ParseFloat(latParam, 64)
+	lng, err2 := strconv.ParseFloat(lngParam, 64)
+	radius, err3 := strconv.ParseFloat(radiusParam, 64)
+	if err1 != nil || err2 != nil || err3 != nil ||
+		lat < -90 || lat > 90 || lng < -180 || lng > 180 || radius <= 0 {
+		logger.Warn("Invalid nearby parameters",
+			zap.String("lat", latParam), zap.String("lng", lngParam), zap.String("radius", radiusParam))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid lat/lng/radius",
+		})
+		return
+	}
+	if radius > maxNearbyRadiusKm {
+		logger.Warn("Nearby radius too large", zap.Float64("radius_km", radius))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Radius too large",
+			Details: "radius must be at most " + strconv.Itoa(maxNearbyRadiusKm) + " km",
+		})
+		return
+	}
+
+	limit, offset, ok := parsePagination(c)
+	if !ok {
+		return
+	}
+
+	locations, err := h.service.GetNearbyLocations(c.Request.Context(), lat, lng, radius, limit, offset)
+	if err != nil {
+		logger.Error("Failed to fetch nearby locations", zap.Error(err))
+		c.JSON(serverErrorStatus(err), dto.ErrorResponse{
+			Message: "Could not fetch nearby locations",
+		})
+		return
+	}
+
+	logger.Info("Fetched nearby locations", zap.Int("count", len(locations)), zap.Float64("radius_km", radius))
+	c.JSON(http.StatusOK, locations)
+}
+
+// GetNearestLocations godoc
+// @Summary Find the k nearest locations
+// @Description Returns the k locations closest to the given point, optionally filtered by marker color
+// @Tags locations
+// @Produce json
+// @Param lat query number true "Latitude"
+// @Param lng query number true "Longitude"
+// @Param k query int false "Number of locations (1-100)" default(5)
+// @Param color query string false "Marker color, e.g. #ff0000"
+// @Success 200 {array} model.NearbyLocation
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/locations/nearest [get]
+func (h *LocationHandler) GetNearestLocations(c *gin.Context) {
+	latParam := c.Query("lat")
+	lngParam := c.Query("lng")
+	kParam := c.DefaultQuery("k", "5")
+
+	lat, err1 := strconv.ParseFloat(latParam, 64)
+	lng, err2 := strconv.ParseFloat(lngParam, 64)
+	k, err3 := strconv.Atoi(kParam)
+	if err1 != nil || err2 != nil || err3 != nil ||
+		lat < -90 || lat > 90 || lng < -180 || lng > 180 || k < 1 || k > maxNearestK {
+		logger.Warn("Invalid nearest parameters",
+			zap.String("lat", latParam), zap.String("lng", lngParam), zap.String("k", kParam))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid lat/lng/k",
+		})
+		return
+	}
+
+	color := c.Query("color")
+	if color != "" && !validation.IsHexColor(color) {
+		logger.Warn("Invalid color filter", zap.String("color", color))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid color",
+		})
+		return
+	}
+
+	locations, err := h.service.GetNearestLocations(c.Request.Context(), lat, lng, k, color)
+	if err != nil {
+		logger.Error("Failed to fetch nearest locations", zap.Error(err))
+		c.JSON(serverErrorStatus(err), dto.ErrorResponse{
+			Message: "Could not fetch nearest locations",
+		})
+		return
+	}
+
+	logger.Info("Fetched nearest locations", zap.Int("count", len(locations)), zap.Int("k", k))
 	c.JSON(http.StatusOK, locations)
 }
 
@@ -126,7 +360,7 @@ func (h *LocationHandler) GetLocationByID(c *gin.Context) {
 		return
 	}
 
-	location, err := h.service.GetLocationByID(uint(id))
+	location, err := h.service.GetLocationByID(c.Request.Context(), uint(id))
 	if err != nil {
 		logger.Warn("Location not found", zap.Int("id", id))
 		c.JSON(http.StatusNotFound, dto.ErrorResponse{
@@ -179,7 +413,7 @@ func (h *LocationHandler) UpdateLocation(c *gin.Context) {
 		return
 	}
 
-	existing, err := h.service.GetLocationByID(uint(id))
+	existing, err := h.service.GetLocationByID(c.Request.Context(), uint(id))
 	if err != nil {
 		logger.Error("Location not found", zap.Error(err))
 		c.JSON(http.StatusNotFound, dto.ErrorResponse{
@@ -194,9 +428,9 @@ func (h *LocationHandler) UpdateLocation(c *gin.Context) {
 	existing.Longitude = req.Longitude
 	existing.Color = req.Color
 
-	if err := h.service.UpdateLocation(existing); err != nil {
+	if err := h.service.UpdateLocation(c.Request.Context(), existing); err != nil {
 		logger.Error("Could not update location", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+		c.JSON(serverErrorStatus(err), dto.ErrorResponse{
 			Message: "Could not update location",
 		})
 		return
@@ -206,39 +440,377 @@ func (h *LocationHandler) UpdateLocation(c *gin.Context) {
 	c.JSON(http.StatusOK, existing)
 }
 
+// DeleteLocation godoc
+// @Summary Delete a location
+// @Description Soft-deletes a location; it is excluded from listings and routes until restored
+// @Tags locations
+// @Param id path int true "Location ID"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/locations/{id} [delete]
+func (h *LocationHandler) DeleteLocation(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		logger.Warn("Invalid ID parameter", zap.String("id", idParam))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid ID",
+		})
+		return
+	}
+
+	if err := h.service.DeleteLocation(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn("Location not found", zap.Int("id", id))
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Message: "Location not found",
+			})
+			return
+		}
+		logger.Error("Could not delete location", zap.Error(err))
+		c.JSON(serverErrorStatus(err), dto.ErrorResponse{
+			Message: "Could not delete location",
+		})
+		return
+	}
+
+	logger.Info("Location deleted", zap.Int("id", id))
+	c.Status(http.StatusNoContent)
+}
+
 // GetRoute godoc
-// @Summary Get route starting from closest location
+// @Summary Get optimised route over all locations
+// @Description Builds a visiting order using nearest-neighbour construction refined with 2-opt and Or-opt. The route starts at the reference point (lat, lng) or at the location start_id, such as a depot, which is then returned as start. end_id fixes the last stop; return_to_start (or end_id equal to start_id) makes a closed tour whose last stop is the start location. The response format is taken from format, or negotiated from the Accept header: geojson is a FeatureCollection with a LineString of the path followed by the stops as Points, gpx a GPX route with an rtept per location, and kml a Placemark per location styled with its color plus the path. GPX and KML are sent as attachments.
 // @Tags locations
 // @Produce json
-// @Param lat query number true "Reference latitude"
-// @Param lng query number true "Reference longitude"
-// @Success 200 {array} model.Location
+// @Produce application/geo+json
+// @Produce application/gpx+xml
+// @Produce application/vnd.google-earth.kml+xml
+// @Param lat query number false "Reference latitude, unless start_id is given"
+// @Param lng query number false "Reference longitude, unless start_id is given"
+// @Param start_id query int false "ID of the location to start at"
+// @Param end_id query int false "ID of the location to finish at"
+// @Param return_to_start query bool false "Finish back at start_id" default(false)
+// @Param unit query string false "Distance unit (km, mi, nmi)" default(km)
+// @Param format query string false "json, geojson, gpx or kml"
+// @Success 200 {object} dto.RouteResponse
 // @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse "Start or end location not found"
 // @Router /api/v1/route [get]
 func (h *LocationHandler) GetRoute(c *gin.Context) {
-	latParam := c.Query("lat")
-	lngParam := c.Query("lng")
+	opts, ok := parseRouteOptions(c)
+	if !ok {
+		return
+	}
 
-	lat, err1 := strconv.ParseFloat(latParam, 64)
-	lng, err2 := strconv.ParseFloat(lngParam, 64)
+	unit, err := dto.ParseDistanceUnit(c.Query("unit"))
+	if err != nil {
+		logger.Warn("Invalid distance unit", zap.String("unit", c.Query("unit")))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid unit",
+			Details: err.Error(),
+		})
+		return
+	}
 
-	if err1 != nil || err2 != nil {
-		logger.Warn("Invalid lat/lng parameters", zap.String("lat", latParam), zap.String("lng", lngParam))
+	responseFormat, ok := routeFormat(c)
+	if !ok {
+		logger.Warn("Invalid route format", zap.String("format", c.Query("format")))
 		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Message: "Invalid lat/lng",
+			Message: "Invalid format",
+			Details: "format must be json, geojson, gpx or kml",
 		})
 		return
 	}
 
-	result, err := h.service.GetRouteFrom(lat, lng)
+	result, err := h.service.GetRoute(c.Request.Context(), opts)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn("Route anchor not found", zap.Error(err))
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Message: "Location not found",
+				Details: err.Error(),
+			})
+			return
+		}
 		logger.Error("Failed to compute route", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+		c.JSON(serverErrorStatus(err), dto.ErrorResponse{
 			Message: "Could not fetch route",
 		})
 		return
 	}
 
-	logger.Info("Route fetched", zap.Int("count", len(result)))
-	c.JSON(http.StatusOK, result)
+	logger.Info("Route fetched", zap.Int("count", len(result.Stops)), zap.Float64("total_distance_km", result.TotalDistance))
+	renderRoute(c, dto.NewRouteResponse(result, unit), responseFormat, opts)
+}
+
+// BuildRoute godoc
+// @Summary Get optimised route over selected locations
+// @Description Builds a route like GET /api/v1/route over a subset of the stored locations: those listed in location_ids and/or having one of colors (both must match when both are given). Every listed ID must exist. The route starts at start or at the location start_id, which need not be part of the selection, and may finish at end_id or back at the start (return_to_start). Response formats are those of GET /api/v1/route.
+// @Tags locations
+// @Accept json
+// @Produce json
+// @Produce application/geo+json
+// @Produce application/gpx+xml
+// @Produce application/vnd.google-earth.kml+xml
+// @Param request body dto.RouteRequest true "Locations and anchors of the route"
+// @Param unit query string false "Distance unit (km, mi, nmi)" default(km)
+// @Param format query string false "json, geojson, gpx or kml"
+// @Success 200 {object} dto.RouteResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse "Selected, start or end location not found"
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/routes [post]
+func (h *LocationHandler) BuildRoute(c *gin.Context) {
+	var req dto.RouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn("Invalid JSON received", zap.Error(err))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid JSON",
+		})
+		return
+	}
+
+	if err := validation.Validator.Struct(req); err != nil {
+		logger.Warn("Validation failed", zap.Error(err))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Validation failed",
+			Details: validation.FormatValidationError(err),
+		})
+		return
+	}
+
+	if len(req.LocationIDs) == 0 && len(req.Colors) == 0 {
+		logger.Warn("Route request selects no locations")
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "No locations selected",
+			Details: "give location_ids and/or colors, or use GET /api/v1/route for every location",
+		})
+		return
+	}
+	if len(req.LocationIDs) > maxRouteLocations {
+		logger.Warn("Route request too large", zap.Int("location_ids", len(req.LocationIDs)))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Too many locations",
+			Details: "at most " + strconv.Itoa(maxRouteLocations) + " location IDs per route",
+		})
+		return
+	}
+
+	opts := service.RouteOptions{
+		LocationIDs:   req.LocationIDs,
+		Colors:        req.Colors,
+		StartID:       req.StartID,
+		EndID:         req.EndID,
+		ReturnToStart: req.ReturnToStart,
+	}
+	if req.Start != nil {
+		opts.Lat, opts.Lng = req.Start.Latitude, req.Start.Longitude
+	}
+	if err := opts.Validate(); err != nil {
+		logger.Warn("Invalid route parameters", zap.Error(err))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid route parameters",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	unit, err := dto.ParseDistanceUnit(c.Query("unit"))
+	if err != nil {
+		logger.Warn("Invalid distance unit", zap.String("unit", c.Query("unit")))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid unit",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	responseFormat, ok := routeFormat(c)
+	if !ok {
+		logger.Warn("Invalid route format", zap.String("format", c.Query("format")))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid format",
+			Details: "format must be json, geojson, gpx or kml",
+		})
+		return
+	}
+
+	result, err := h.service.GetRoute(c.Request.Context(), opts)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warn("Route location not found", zap.Error(err))
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Message: "Location not found",
+				Details: err.Error(),
+			})
+			return
+		}
+		logger.Error("Failed to compute route", zap.Error(err))
+		c.JSON(serverErrorStatus(err), dto.ErrorResponse{
+			Message: "Could not fetch route",
+		})
+		return
+	}
+
+	logger.Info("Route built", zap.Int("selected", len(req.LocationIDs)), zap.Int("count", len(result.Stops)), zap.Float64("total_distance_km", result.TotalDistance))
+	renderRoute(c, dto.NewRouteResponse(result, unit), responseFormat, opts)
+}
+
+// renderRoute writes a route in the given format. Paths of routes from a
+// reference point start at the point of opts.
+func renderRoute(c *gin.Context, response dto.RouteResponse, responseFormat string, opts service.RouteOptions) {
+	switch responseFormat {
+	case format.GeoJSON:
+		renderGeoJSON(c, format.RouteGeoJSON(response, opts.Lat, opts.Lng))
+	case format.GPX:
+		renderFile(c, format.MIMEGPX, "route.gpx", func(w io.Writer) error {
+			return format.WriteRouteGPX(w, response)
+		})
+	case format.KML:
+		renderFile(c, format.MIMEKML, "route.kml", func(w io.Writer) error {
+			return format.WriteRouteKML(w, response, opts.Lat, opts.Lng)
+		})
+	default:
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// parsePagination reads the limit and offset query parameters, writing a 400
+// response and returning ok=false when they are invalid.
+func parsePagination(c *gin.Context) (limit, offset int, ok bool) {
+	limitParam := c.DefaultQuery("limit", "10")
+	offsetParam := c.DefaultQuery("offset", "0")
+
+	limit, err1 := strconv.Atoi(limitParam)
+	offset, err2 := strconv.Atoi(offsetParam)
+	if err1 != nil || err2 != nil || limit < 1 || offset < 0 {
+		logger.Warn("Invalid pagination parameters", zap.String("limit", limitParam), zap.String("offset", offsetParam))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid pagination parameters",
+		})
+		return 0, 0, false
+	}
+	return limit, offset, true
+}
+
+// parseRouteOptions reads the start and end of a route: either a reference
+// point (lat, lng) or a start_id, plus the optional end_id and
+// return_to_start. It writes a 400 response and returns ok=false when they
+// are invalid.
+func parseRouteOptions(c *gin.Context) (opts service.RouteOptions, ok bool) {
+	latParam := c.Query("lat")
+	lngParam := c.Query("lng")
+	startParam := c.Query("start_id")
+	endParam := c.Query("end_id")
+	returnParam := c.DefaultQuery("return_to_start", "false")
+
+	fail := func(message, details string) (service.RouteOptions, bool) {
+		logger.Warn(message, zap.String("lat", latParam), zap.String("lng", lngParam),
+			zap.String("start_id", startParam), zap.String("end_id", endParam), zap.String("return_to_start", returnParam))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: message,
+			Details: details,
+		})
+		return service.RouteOptions{}, false
+	}
+
+	if startParam != "" {
+		if latParam != "" || lngParam != "" {
+			return fail("Invalid route parameters", "give either lat/lng or start_id, not both")
+		}
+		id, err := strconv.ParseUint(startParam, 10, 32)
+		if err != nil || id == 0 {
+			return fail("Invalid start_id", "")
+		}
+		opts.StartID = uint(id)
+	} else {
+		lat, err1 := strconv.ParseFloat(latParam, 64)
+		lng, err2 := strconv.ParseFloat(lngParam, 64)
+		if err1 != nil || err2 != nil {
+			return fail("Invalid lat/lng", "")
+		}
+		opts.Lat, opts.Lng = lat, lng
+	}
+
+	if endParam != "" {
+		id, err := strconv.ParseUint(endParam, 10, 32)
+		if err != nil || id == 0 {
+			return fail("Invalid end_id", "")
+		}
+		opts.EndID = uint(id)
+	}
+
+	closed, err := strconv.ParseBool(returnParam)
+	if err != nil {
+		return fail("Invalid return_to_start", "return_to_start must be a boolean")
+	}
+	opts.ReturnToStart = closed
+
+	if err := opts.Validate(); err != nil {
+		return fail("Invalid route parameters", err.Error())
+	}
+	return opts, true
+}
+
+// wantsGeoJSON reports whether the Accept header prefers GeoJSON over JSON.
+// Responses vary by Accept either way.
+func wantsGeoJSON(c *gin.Context) bool {
+	c.Header("Vary", "Accept")
+	return c.NegotiateFormat(gin.MIMEJSON, format.MIMEGeoJSON) == format.MIMEGeoJSON
+}
+
+func renderGeoJSON(c *gin.Context, fc format.FeatureCollection) {
+	c.Header("Content-Type", format.MIMEGeoJSON)
+	c.JSON(http.StatusOK, fc)
+}
+
+// routeFormat returns the format of a route response: the format query
+// parameter if given, otherwise the one the Accept header prefers.
+func routeFormat(c *gin.Context) (string, bool) {
+	c.Header("Vary", "Accept")
+	switch requested := c.Query("format"); requested {
+	case "":
+	case "json", format.GeoJSON, format.GPX, format.KML:
+		return requested, true
+	default:
+		return "", false
+	}
+
+	switch c.NegotiateFormat(gin.MIMEJSON, format.MIMEGeoJSON, format.MIMEGPX, format.MIMEKML) {
+	case format.MIMEGeoJSON:
+		return format.GeoJSON, true
+	case format.MIMEGPX:
+		return format.GPX, true
+	case format.MIMEKML:
+		return format.KML, true
+	default:
+		return "json", true
+	}
+}
+
+// renderFile sends what write produces as a downloadable file. The body is
+// rendered up front so that a failure can still be reported as a 500.
+func renderFile(c *gin.Context, contentType, filename string, write func(io.Writer) error) {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		logger.Error("Could not render file", zap.String("filename", filename), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+			Message: "Could not render " + filename,
+		})
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, contentType+"; charset=utf-8", buf.Bytes())
+}
+
+// serverErrorStatus returns the status for an unexpected service error:
+// 504 when the request deadline expired, 500 otherwise.
+func serverErrorStatus(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
 }
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yusufbulac/location-routing-service/internal/dto"
+	"github.com/yusufbulac/location-routing-service/internal/logger"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+	"github.com/yusufbulac/location-routing-service/internal/service"
+	"github.com/yusufbulac/location-routing-service/internal/validation"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// GetDistanceMatrix godoc
+// @Summary Get the distance matrix between sets of points
+// @Description Returns the great-circle distance and estimated duration from every origin to every destination. Each point is a stored location (id) or a latitude/longitude pair; without destinations the matrix is square over the origins. Durations are in seconds at speed_kmh, or the server's default speed. The number of points per side and of matrix elements are limited by the server configuration.
+// @Tags locations
+// @Accept json
+// @Produce json
+// @Param request body dto.MatrixRequest true "Origins and destinations"
+// @Param unit query string false "Distance unit (km, mi, nmi)" default(km)
+// @Success 200 {object} dto.MatrixResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse "Location not found"
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/matrix [post]
+func (h *LocationHandler) GetDistanceMatrix(c *gin.Context) {
+	var req dto.MatrixRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn("Invalid JSON received", zap.Error(err))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid JSON",
+		})
+		return
+	}
+
+	if err := validation.Validator.Struct(req); err != nil {
+		logger.Warn("Validation failed", zap.Error(err))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Validation failed",
+			Details: validation.FormatValidationError(err),
+		})
+		return
+	}
+
+	var problems []string
+	origins := matrixPoints("origins", req.Origins, &problems)
+	destinations := origins
+	if len(req.Destinations) > 0 {
+		destinations = matrixPoints("destinations", req.Destinations, &problems)
+	}
+	if len(problems) > 0 {
+		logger.Warn("Invalid matrix points", zap.Int("errors", len(problems)))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Validation failed",
+			Details: strings.Join(problems, "; "),
+		})
+		return
+	}
+
+	unit, err := dto.ParseDistanceUnit(c.Query("unit"))
+	if err != nil {
+		logger.Warn("Invalid distance unit", zap.String("unit", c.Query("unit")))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid unit",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	matrix, err := h.service.DistanceMatrix(c.Request.Context(), origins, destinations, req.SpeedKmh)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrMatrixTooLarge):
+			logger.Warn("Distance matrix too large", zap.Int("origins", len(origins)), zap.Int("destinations", len(destinations)))
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Message: "Matrix too large",
+				Details: err.Error(),
+			})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			logger.Warn("Matrix location not found", zap.Error(err))
+			c.JSON(http.StatusNotFound, dto.ErrorResponse{
+				Message: "Location not found",
+				Details: err.Error(),
+			})
+		default:
+			logger.Error("Failed to compute distance matrix", zap.Error(err))
+			c.JSON(serverErrorStatus(err), dto.ErrorResponse{
+				Message: "Could not compute distance matrix",
+			})
+		}
+		return
+	}
+
+	logger.Info("Distance matrix computed", zap.Int("origins", len(origins)), zap.Int("destinations", len(destinations)))
+	c.JSON(http.StatusOK, dto.NewMatrixResponse(matrix, unit))
+}
+
+// matrixPoints converts the points of one side of a matrix request, adding a
+// problem for each invalid point by its index.
+func matrixPoints(side string, requests []dto.MatrixPointRequest, problems *[]string) []model.MatrixPoint {
+	points := make([]model.MatrixPoint, 0, len(requests))
+	for i, req := range requests {
+		point, err := req.Point()
+		if err != nil {
+			*problems = append(*problems, fmt.Sprintf("%s[%d]: %v", side, i, err))
+			continue
+		}
+		points = append(points, point)
+	}
+	return points
+}
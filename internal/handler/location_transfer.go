@@ -0,0 +1,292 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yusufbulac/location-routing-service/internal/dto"
+	"github.com/yusufbulac/location-routing-service/internal/format"
+	"github.com/yusufbulac/location-routing-service/internal/logger"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+	"github.com/yusufbulac/location-routing-service/internal/validation"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxImportRows caps the number of records of an imported file.
+	maxImportRows = 100000
+	// maxImportErrors caps the number of record errors listed in an import response.
+	maxImportErrors = 1000
+)
+
+// ImportLocations godoc
+// @Summary Import locations from CSV, GeoJSON, GPX or KML
+// @Description Reads a CSV file with a header row, a GeoJSON FeatureCollection of Points, a GPX file of waypoints or route points, or the Point placemarks of a KML file, either as the request body (by Content-Type) or as the "file" field of a multipart form (by file extension); format overrides both. CSV columns are found by their header names (name, latitude/lat, longitude/lng/lon, color); columns[field]=header maps a field to another header. GeoJSON features take name and color from their properties, KML placemarks their color from the icon style. color sets the color of records without one, such as GPX waypoints. Records are validated as they are read and the valid ones stored in a single transaction. In atomic mode (the default) nothing is stored if any record is invalid; in best_effort mode the valid records are stored. dry_run=true only validates.
+// @Tags locations
+// @Accept text/csv
+// @Accept application/geo+json
+// @Accept application/gpx+xml
+// @Accept application/vnd.google-earth.kml+xml
+// @Accept multipart/form-data
+// @Produce json
+// @Param mode query string false "atomic or best_effort" default(atomic)
+// @Param dry_run query bool false "Validate without storing" default(false)
+// @Param format query string false "csv, geojson, gpx or kml"
+// @Param color query string false "Color (#rrggbb) of records without one"
+// @Param columns[name] query string false "Header of the CSV name column"
+// @Param columns[latitude] query string false "Header of the CSV latitude column"
+// @Param columns[longitude] query string false "Header of the CSV longitude column"
+// @Param columns[color] query string false "Header of the CSV color column"
+// @Success 200 {object} dto.ImportResponse "Dry run"
+// @Success 201 {object} dto.ImportResponse
+// @Failure 400 {object} dto.ImportResponse
+// @Failure 413 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/locations/import [post]
+func (h *LocationHandler) ImportLocations(c *gin.Context) {
+	mode := c.DefaultQuery("mode", dto.BatchModeAtomic)
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	defaultColor := c.Query("color")
+	if err != nil || (mode != dto.BatchModeAtomic && mode != dto.BatchModeBestEffort) ||
+		(defaultColor != "" && !validation.IsHexColor(defaultColor)) {
+		logger.Warn("Invalid import parameters", zap.String("mode", mode), zap.String("dry_run", c.Query("dry_run")), zap.String("color", defaultColor))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid import parameters",
+			Details: "mode must be atomic or best_effort, dry_run a boolean and color a #rrggbb color",
+		})
+		return
+	}
+
+	body, fileFormat, err := importBody(c)
+	if err != nil {
+		if importTooLarge(c, err) {
+			return
+		}
+		logger.Warn("Invalid import upload", zap.Error(err))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid upload",
+			Details: err.Error(),
+		})
+		return
+	}
+	defer body.Close()
+
+	var decoder format.Decoder
+	switch fileFormat {
+	case format.GeoJSON:
+		decoder, err = format.NewGeoJSONDecoder(body)
+	case format.GPX:
+		decoder = format.NewGPXDecoder(body)
+	case format.KML:
+		decoder = format.NewKMLDecoder(body)
+	default:
+		decoder, err = format.NewCSVDecoder(body, c.QueryMap("columns"))
+	}
+	if err != nil {
+		if importTooLarge(c, err) {
+			return
+		}
+		logger.Warn("Invalid import file", zap.Error(err))
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid import file",
+			Details: err.Error(),
+		})
+		return
+	}
+
+	response := dto.ImportResponse{Mode: mode, DryRun: dryRun, Errors: []dto.ImportRowError{}}
+	recordError := func(pos format.Position, details string) {
+		response.Invalid++
+		if len(response.Errors) < maxImportErrors {
+			response.Errors = append(response.Errors, dto.ImportRowError{Index: pos.Index, Row: pos.Line, Details: details})
+		} else {
+			response.ErrorsTruncated = true
+		}
+	}
+
+	var locations []model.Location
+	for {
+		req, err := decoder.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var recErr *format.RecordError
+		if err != nil && !errors.As(err, &recErr) {
+			if importTooLarge(c, err) {
+				return
+			}
+			logger.Warn("Could not read import file", zap.Error(err))
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Message: "Could not read import file",
+				Details: err.Error(),
+			})
+			return
+		}
+
+		response.Rows++
+		if response.Rows > maxImportRows {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+				Message: "Import file too large",
+				Details: "at most " + strconv.Itoa(maxImportRows) + " records per import",
+			})
+			return
+		}
+		if recErr != nil {
+			recordError(recErr.Position, recErr.Err.Error())
+			continue
+		}
+		if req.Color == "" {
+			req.Color = defaultColor
+		}
+		if err := validation.Validator.Struct(req); err != nil {
+			recordError(decoder.Position(), validation.FormatValidationError(err))
+			continue
+		}
+		locations = append(locations, model.Location{
+			Name:      req.Name,
+			Latitude:  req.Latitude,
+			Longitude: req.Longitude,
+			Color:     req.Color,
+		})
+	}
+	response.Valid = len(locations)
+
+	rejected := len(locations) == 0 || (mode == dto.BatchModeAtomic && response.Invalid > 0)
+	if dryRun {
+		logger.Info("Import dry run", zap.Int("rows", response.Rows), zap.Int("invalid", response.Invalid))
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	if rejected {
+		logger.Warn("Import rejected", zap.String("mode", mode), zap.Int("rows", response.Rows), zap.Int("invalid", response.Invalid))
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if err := h.service.CreateLocations(c.Request.Context(), locations); err != nil {
+		logger.Error("Could not import locations", zap.Error(err))
+		c.JSON(serverErrorStatus(err), dto.ErrorResponse{
+			Message: "Could not import locations",
+		})
+		return
+	}
+	response.Created = len(locations)
+
+	logger.Info("Locations imported", zap.Int("created", response.Created), zap.Int("invalid", response.Invalid))
+	c.JSON(http.StatusCreated, response)
+}
+
+// importTooLarge responds with 413 if err stems from a request body over the
+// limit set by middleware.MaxBodySize, and reports whether it did.
+func importTooLarge(c *gin.Context, err error) bool {
+	var tooLarge *http.MaxBytesError
+	if !errors.As(err, &tooLarge) {
+		return false
+	}
+	logger.Warn("Import body too large", zap.Int64("limit", tooLarge.Limit))
+	c.JSON(http.StatusRequestEntityTooLarge, dto.ErrorResponse{
+		Message: "Import file too large",
+		Details: "at most " + strconv.FormatInt(tooLarge.Limit, 10) + " bytes per import",
+	})
+	return true
+}
+
+// importBody returns the uploaded file of a multipart form, or the request
+// body, and its format. The format query parameter wins over the file
+// extension or Content-Type; anything unrecognised is read as CSV.
+func importBody(c *gin.Context) (io.ReadCloser, string, error) {
+	fileFormat := c.Query("format")
+	switch fileFormat {
+	case "", format.CSV, format.GeoJSON, format.GPX, format.KML:
+	default:
+		return nil, "", fmt.Errorf("unknown format %q (expected csv, geojson, gpx or kml)", fileFormat)
+	}
+
+	body := c.Request.Body
+	detected := format.FromMediaType(c.GetHeader("Content-Type"))
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", err
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		body, detected = file, format.FromFilename(header.Filename)
+	}
+
+	if fileFormat == "" {
+		fileFormat = detected
+	}
+	if fileFormat == "" {
+		fileFormat = format.CSV
+	}
+	return body, fileFormat, nil
+}
+
+// exportStatusTrailer is the trailer telling whether a streamed export is complete.
+const exportStatusTrailer = "X-Export-Status"
+
+// ExportLocationsCSV godoc
+// @Summary Export locations as CSV
+// @Description Streams every location as CSV (id, name, latitude, longitude, color, created_at, updated_at), reading the table in batches. The X-Export-Status trailer is "complete" when every location was written and "truncated" when the export failed after the response started.
+// @Tags locations
+// @Produce text/csv
+// @Success 200 {string} string "CSV file"
+// @Header 200 {string} X-Export-Status "complete or truncated, sent as a trailer"
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/locations/export.csv [get]
+func (h *LocationHandler) ExportLocationsCSV(c *gin.Context) {
+	writer := format.NewCSVWriter(c.Writer)
+	started := false
+	exported := 0
+	start := func() {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="locations.csv"`)
+		c.Header("Trailer", exportStatusTrailer)
+		c.Status(http.StatusOK)
+		started = true
+	}
+
+	err := h.service.StreamLocations(c.Request.Context(), func(batch []model.Location) error {
+		if !started {
+			start()
+		}
+		if err := writer.Write(batch); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		exported += len(batch)
+		return nil
+	})
+	if err != nil {
+		logger.Error("Could not export locations", zap.Error(err), zap.Int("exported", exported))
+		if !started {
+			c.JSON(serverErrorStatus(err), dto.ErrorResponse{
+				Message: "Could not export locations",
+			})
+			return
+		}
+		// the status line is already sent; only the trailer can report the failure
+		c.Writer.Header().Set(exportStatusTrailer, "truncated")
+		c.Abort()
+		return
+	}
+
+	if !started {
+		start()
+		if err := writer.Write(nil); err != nil {
+			logger.Error("Could not export locations", zap.Error(err))
+			c.Writer.Header().Set(exportStatusTrailer, "truncated")
+			return
+		}
+	}
+	c.Writer.Header().Set(exportStatusTrailer, "complete")
+	logger.Info("Locations exported as CSV", zap.Int("count", exported))
+}
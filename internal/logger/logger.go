@@ -4,7 +4,8 @@ import (
 	"go.uber.org/zap"
 )
 
-var Log *zap.Logger
+// Log is a no-op logger until InitLogger is called, so packages can log safely in tests.
+var Log = zap.NewNop()
 
 func InitLogger() {
 	var err error
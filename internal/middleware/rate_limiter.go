@@ -10,11 +10,11 @@ import (
 	memory "github.com/ulule/limiter/v3/drivers/store/memory"
 )
 
-func RateLimitMiddleware() gin.HandlerFunc {
-	// Rate limit middleware: max 10 requests per minute per IP
+// RateLimitMiddleware allows at most limit requests per client IP every period.
+func RateLimitMiddleware(limit int64, period time.Duration) gin.HandlerFunc {
 	rate := limiter.Rate{
-		Period: 1 * time.Minute,
-		Limit:  10,
+		Period: period,
+		Limit:  limit,
 	}
 	store := memory.NewStore()
 	limiterInstance := limiter.New(store, rate)
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestTimeout bounds the context of every request by timeout, so database
+// and cache calls made on behalf of a slow request are cancelled. A timeout
+// of zero or less leaves requests unbounded, as are requests to the exempt
+// route paths, which set their own deadline.
+func RequestTimeout(timeout time.Duration, exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 || slices.Contains(exempt, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
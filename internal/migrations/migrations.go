@@ -0,0 +1,438 @@
+// Package migrations applies the versioned SQL scripts that define the
+// database schema. Scripts are embedded per dialect and named
+// NNNN_description.up.sql / NNNN_description.down.sql; applied versions are
+// recorded in the schema_migrations table.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed mysql postgres sqlite
+var scripts embed.FS
+
+// legacyDeletedAtTypes are the types of locations.deleted_at created by
+// migration 1, used to add the column to tables from before soft deletes.
+var legacyDeletedAtTypes = map[string]string{
+	"mysql":    "DATETIME(3) NULL",
+	"postgres": "TIMESTAMPTZ",
+	"sqlite":   "DATETIME",
+}
+
+// legacyIndexes are the locations indexes created by migration 1.
+var legacyIndexes = []struct{ name, columns string }{
+	{"idx_locations_lat_lng", "latitude, longitude"},
+	{"idx_locations_deleted_at", "deleted_at"},
+}
+
+var (
+	// ErrSchemaBehind is returned by CheckCurrent when migrations are pending.
+	ErrSchemaBehind = errors.New("database schema is behind")
+	// ErrUnknownVersion is returned by To for a version that has no migration.
+	ErrUnknownVersion = errors.New("unknown migration version")
+)
+
+// Migration is a single versioned schema change.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status reports whether a migration has been applied.
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Applied reports whether the migration has been applied.
+func (s Status) Applied() bool {
+	return s.AppliedAt != nil
+}
+
+type appliedMigration struct {
+	Version   int64
+	AppliedAt time.Time
+}
+
+// Migrator applies the migrations of the database dialect of db.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New returns a migrator for the embedded scripts of the dialect of db.
+func New(db *gorm.DB) (*Migrator, error) {
+	migrations, err := load(scripts, db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Latest returns the highest known migration version.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version returns the highest applied migration version, or 0 for an empty database.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var version int64
+	for v := range applied {
+		version = max(version, v)
+	}
+	return version, nil
+}
+
+// Status lists every known migration in version order.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, mig := range m.migrations {
+		statuses[i] = Status{Version: mig.Version, Name: mig.Name}
+		if at, ok := applied[mig.Version]; ok {
+			at := at
+			statuses[i].AppliedAt = &at
+		}
+	}
+	return statuses, nil
+}
+
+// CheckCurrent returns an error wrapping ErrSchemaBehind if any migration is pending.
+func (m *Migrator) CheckCurrent(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, s := range statuses {
+		if !s.Applied() {
+			pending = append(pending, strconv.FormatInt(s.Version, 10))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: pending migrations %s", ErrSchemaBehind, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// Up applies every pending migration in version order and returns them.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.upTo(ctx, m.Latest())
+}
+
+// Down rolls back the most recently applied migration. It returns nil if
+// no migration is applied.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[m.migrations[i].Version]; ok {
+			mig := m.migrations[i]
+			if err := m.run(ctx, mig, false); err != nil {
+				return nil, err
+			}
+			return &mig, nil
+		}
+	}
+	return nil, nil
+}
+
+// To migrates up or down so that exactly the migrations up to version are
+// applied. Version 0 rolls back every migration. It returns the migrations
+// that were applied or rolled back, in the order they ran.
+func (m *Migrator) To(ctx context.Context, version int64) ([]Migration, error) {
+	if version != 0 && m.find(version) == nil {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	rolledBack, err := m.downTo(ctx, version)
+	if err != nil {
+		return rolledBack, err
+	}
+	applied, err := m.upTo(ctx, version)
+	return append(rolledBack, applied...), err
+}
+
+func (m *Migrator) upTo(ctx context.Context, version int64) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for _, mig := range m.migrations {
+		if mig.Version > version {
+			break
+		}
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		if err := m.run(ctx, mig, true); err != nil {
+			return ran, err
+		}
+		ran = append(ran, mig)
+	}
+	return ran, nil
+}
+
+func (m *Migrator) downTo(ctx context.Context, version int64) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for i := len(m.migrations) - 1; i >= 0 && m.migrations[i].Version > version; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		if err := m.run(ctx, mig, false); err != nil {
+			return ran, err
+		}
+		ran = append(ran, mig)
+	}
+	return ran, nil
+}
+
+// run executes one direction of mig and records the result in a single
+// transaction. MySQL commits DDL implicitly, so a failing MySQL script can
+// leave earlier statements of the same script applied.
+func (m *Migrator) run(ctx context.Context, mig Migration, up bool) error {
+	script, direction := mig.Down, "down"
+	if up {
+		script, direction = mig.Up, "up"
+	}
+
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range statements(script) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		if up {
+			return tx.Exec("INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)", mig.Version, time.Now().UTC()).Error
+		}
+		return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", mig.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %04d_%s %s: %w", mig.Version, mig.Name, direction, err)
+	}
+	return nil
+}
+
+// applied ensures the schema_migrations table exists and returns the
+// applied versions with their timestamps.
+func (m *Migrator) applied(ctx context.Context) (map[int64]time.Time, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var rows []appliedMigration
+	if err := m.db.WithContext(ctx).Raw("SELECT version, applied_at FROM schema_migrations").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+
+	applied := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+	return applied, nil
+}
+
+// ensureTable creates schema_migrations. A database whose locations table
+// already exists without it was created by AutoMigrate and is baselined by
+// baselineLegacy instead of being migrated from scratch.
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	db := m.db.WithContext(ctx)
+	if db.Migrator().HasTable("schema_migrations") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`CREATE TABLE schema_migrations (
+			version BIGINT NOT NULL PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL
+		)`).Error
+		if err != nil {
+			return fmt.Errorf("create schema_migrations: %w", err)
+		}
+
+		if !tx.Migrator().HasTable("locations") {
+			return nil
+		}
+		return baselineLegacy(tx)
+	})
+}
+
+// baselineLegacy records the versions an AutoMigrate-created locations table
+// already has. Depending on the release that created it, the table may lack
+// the soft-delete column and the indexes of version 1, which are added before
+// the version is recorded. Version 2 is recorded only if its indexed position
+// column exists; otherwise it runs like any pending migration.
+func baselineLegacy(tx *gorm.DB) error {
+	migrator := tx.Migrator()
+	if !migrator.HasColumn("locations", "deleted_at") {
+		stmt := "ALTER TABLE locations ADD COLUMN deleted_at " + legacyDeletedAtTypes[tx.Dialector.Name()]
+		if err := tx.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("baseline locations: %w", err)
+		}
+	}
+	for _, index := range legacyIndexes {
+		if migrator.HasIndex("locations", index.name) {
+			continue
+		}
+		if err := tx.Exec(fmt.Sprintf("CREATE INDEX %s ON locations (%s)", index.name, index.columns)).Error; err != nil {
+			return fmt.Errorf("baseline locations: %w", err)
+		}
+	}
+
+	versions := []int64{1}
+	if migrator.HasColumn("locations", "position") && migrator.HasIndex("locations", "idx_locations_position") {
+		versions = append(versions, 2)
+	}
+	now := time.Now().UTC()
+	for _, version := range versions {
+		if err := tx.Exec("INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)", version, now).Error; err != nil {
+			return fmt.Errorf("baseline schema_migrations: %w", err)
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// load reads the up and down scripts in dir of fsys, sorted by version.
+// Every version needs both scripts.
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %q: %w", dir, err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		version, name, up, err := parseFilename(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: name}
+			byVersion[version] = mig
+		} else if mig.Name != name {
+			return nil, fmt.Errorf("migration %04d has conflicting names %q and %q", version, mig.Name, name)
+		}
+		if up {
+			mig.Up = string(content)
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both up and down scripts", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// parseFilename splits "0001_create_locations.up.sql" into its version,
+// name and direction.
+func parseFilename(filename string) (version int64, name string, up bool, err error) {
+	base, ok := strings.CutSuffix(filename, ".sql")
+	if ok {
+		if base, ok = strings.CutSuffix(base, ".up"); ok {
+			up = true
+		} else {
+			base, ok = strings.CutSuffix(base, ".down")
+		}
+	}
+	number, name, found := strings.Cut(base, "_")
+	if !ok || !found || name == "" {
+		return 0, "", false, fmt.Errorf("invalid migration filename %q", filename)
+	}
+
+	version, err = strconv.ParseInt(number, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, "", false, fmt.Errorf("invalid migration version in %q", filename)
+	}
+	return version, name, up, nil
+}
+
+// statements splits a script into its statements. Statements end with a
+// semicolon at the end of a line; lines starting with "--" are comments.
+// A script of only comments has no statements.
+func statements(script string) []string {
+	var (
+		stmts   []string
+		current strings.Builder
+	)
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+		current.Reset()
+	}
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		if stmt, ok := strings.CutSuffix(trimmed, ";"); ok {
+			current.WriteString(stmt)
+			flush()
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+	}
+	flush()
+	return stmts
+}
@@ -0,0 +1,200 @@
+package migrations
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+filepath.Join(t.TempDir(), "migrations.db")), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func versions(ran []Migration) []int64 {
+	out := make([]int64, len(ran))
+	for i, mig := range ran {
+		out[i] = mig.Version
+	}
+	return out
+}
+
+func TestMigrator_UpDownAndStatus(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	migrator, err := New(db)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, migrator.CheckCurrent(ctx), ErrSchemaBehind)
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, versions(applied))
+	assert.NoError(t, migrator.CheckCurrent(ctx))
+	require.NoError(t, db.Create(&model.Location{Name: "A", Latitude: 1, Longitude: 2, Color: "#ff0000"}).Error)
+
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied, "up is idempotent")
+
+	rolledBack, err := migrator.Down(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, rolledBack)
+	assert.Equal(t, int64(3), rolledBack.Version)
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	assert.True(t, statuses[1].Applied())
+	assert.False(t, statuses[2].Applied())
+	assert.ErrorIs(t, migrator.CheckCurrent(ctx), ErrSchemaBehind)
+
+	version, err := migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), version)
+}
+
+func TestMigrator_To(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	migrator, err := New(db)
+	require.NoError(t, err)
+
+	ran, err := migrator.To(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, versions(ran))
+
+	ran, err = migrator.To(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, versions(ran))
+
+	ran, err = migrator.To(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 1}, versions(ran), "rolls back newest first")
+	assert.False(t, db.Migrator().HasTable("locations"))
+
+	_, err = migrator.To(ctx, 42)
+	assert.ErrorIs(t, err, ErrUnknownVersion)
+}
+
+func TestMigrator_BaselinesAutoMigratedDatabase(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	require.NoError(t, db.AutoMigrate(&model.Location{}))
+
+	migrator, err := New(db)
+	require.NoError(t, err)
+	assert.ErrorIs(t, migrator.CheckCurrent(ctx), ErrSchemaBehind)
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 3}, versions(applied), "version 2 runs without the position column")
+}
+
+// firstReleaseLocation is the model AutoMigrate created the locations table
+// from in the first release, before soft deletes and the coordinate index.
+type firstReleaseLocation struct {
+	ID        uint    `gorm:"primaryKey"`
+	Name      string  `gorm:"type:varchar(100);not null"`
+	Latitude  float64 `gorm:"not null"`
+	Longitude float64 `gorm:"not null"`
+	Color     string  `gorm:"type:char(7);not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (firstReleaseLocation) TableName() string { return "locations" }
+
+func TestMigrator_CompletesFirstReleaseSchema(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	require.NoError(t, db.AutoMigrate(&firstReleaseLocation{}))
+	require.NoError(t, db.Create(&firstReleaseLocation{Name: "A", Latitude: 1, Longitude: 2, Color: "#ff0000"}).Error)
+
+	migrator, err := New(db)
+	require.NoError(t, err)
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 3}, versions(applied))
+
+	assert.True(t, db.Migrator().HasColumn("locations", "deleted_at"))
+	assert.True(t, db.Migrator().HasIndex("locations", "idx_locations_lat_lng"))
+	assert.True(t, db.Migrator().HasIndex("locations", "idx_locations_deleted_at"))
+
+	var location model.Location
+	require.NoError(t, db.First(&location).Error)
+	require.NoError(t, db.Delete(&location).Error)
+	var live int64
+	require.NoError(t, db.Model(&model.Location{}).Count(&live).Error)
+	assert.Zero(t, live, "existing rows can be soft-deleted")
+}
+
+func TestMigrator_FailedMigrationIsNotRecorded(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	migrator := &Migrator{db: db, migrations: []Migration{
+		{Version: 1, Name: "broken", Up: "CREATE TABLE broken (id INTEGER);\nNOT SQL;", Down: "DROP TABLE broken;"},
+	}}
+
+	_, err := migrator.Up(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "0001_broken up")
+
+	version, err := migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, version)
+	assert.False(t, db.Migrator().HasTable("broken"), "the failed script is rolled back")
+}
+
+func TestLoad_EveryDialectHasTheSameVersions(t *testing.T) {
+	sqliteMigrations, err := load(scripts, "sqlite")
+	require.NoError(t, err)
+
+	for _, dialect := range []string{"mysql", "postgres"} {
+		migrations, err := load(scripts, dialect)
+		require.NoError(t, err, dialect)
+		require.Len(t, migrations, len(sqliteMigrations), dialect)
+		for i, mig := range migrations {
+			assert.Equal(t, sqliteMigrations[i].Version, mig.Version, dialect)
+			assert.Equal(t, sqliteMigrations[i].Name, mig.Name, dialect)
+		}
+	}
+}
+
+func TestLoad_RejectsIncompleteMigrations(t *testing.T) {
+	_, err := load(fstest.MapFS{"db/0001_init.up.sql": {Data: []byte("SELECT 1;")}}, "db")
+	assert.ErrorContains(t, err, "needs both up and down scripts")
+
+	_, err = load(fstest.MapFS{"db/init.up.sql": {Data: []byte("SELECT 1;")}}, "db")
+	assert.ErrorContains(t, err, "invalid migration")
+
+	_, err = load(fstest.MapFS{}, "oracle")
+	assert.ErrorContains(t, err, `no migrations for dialect "oracle"`)
+}
+
+func TestStatements(t *testing.T) {
+	script := `-- comment only line
+CREATE TABLE a (
+    id INTEGER
+);
+CREATE INDEX idx ON a (id);
+`
+	assert.Equal(t, []string{"CREATE TABLE a (\n    id INTEGER\n)", "CREATE INDEX idx ON a (id)"}, statements(script))
+	assert.Empty(t, statements("-- nothing to do\n"))
+}
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS locations (
+    id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    name VARCHAR(100) NOT NULL,
+    latitude DOUBLE NOT NULL,
+    longitude DOUBLE NOT NULL,
+    color CHAR(7) NOT NULL,
+    created_at DATETIME(3) NULL,
+    updated_at DATETIME(3) NULL,
+    deleted_at DATETIME(3) NULL,
+    PRIMARY KEY (id),
+    INDEX idx_locations_lat_lng (latitude, longitude),
+    INDEX idx_locations_deleted_at (deleted_at)
+);
@@ -0,0 +1,2 @@
+DROP INDEX idx_locations_position ON locations;
+ALTER TABLE locations DROP COLUMN position;
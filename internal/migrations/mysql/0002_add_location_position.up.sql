@@ -0,0 +1,5 @@
+-- position is generated from latitude/longitude, so it is backfilled for
+-- existing rows and kept current without application changes
+ALTER TABLE locations ADD COLUMN position POINT SRID 4326
+    GENERATED ALWAYS AS (ST_SRID(POINT(longitude, latitude), 4326)) STORED NOT NULL;
+CREATE SPATIAL INDEX idx_locations_position ON locations (position);
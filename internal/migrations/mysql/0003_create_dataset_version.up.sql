@@ -0,0 +1,8 @@
+-- a single row counting writes to locations, bumped in the same transaction
+-- as each write so every process can tell when its cached view is stale
+CREATE TABLE IF NOT EXISTS dataset_version (
+    id TINYINT UNSIGNED NOT NULL,
+    version BIGINT NOT NULL,
+    PRIMARY KEY (id)
+);
+INSERT INTO dataset_version (id, version) VALUES (1, 0);
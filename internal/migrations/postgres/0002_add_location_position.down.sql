@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_locations_position;
+ALTER TABLE locations DROP COLUMN IF EXISTS position;
@@ -0,0 +1,5 @@
+-- geography points behind a GiST index serve ST_DWithin filters and <-> ordering
+CREATE EXTENSION IF NOT EXISTS postgis;
+ALTER TABLE locations ADD COLUMN IF NOT EXISTS position geography(Point, 4326)
+    GENERATED ALWAYS AS (ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography) STORED;
+CREATE INDEX IF NOT EXISTS idx_locations_position ON locations USING GIST (position);
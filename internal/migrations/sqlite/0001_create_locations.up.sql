@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS locations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(100) NOT NULL,
+    latitude REAL NOT NULL,
+    longitude REAL NOT NULL,
+    color CHAR(7) NOT NULL,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_locations_lat_lng ON locations (latitude, longitude);
+CREATE INDEX IF NOT EXISTS idx_locations_deleted_at ON locations (deleted_at);
@@ -0,0 +1,2 @@
+-- SQLite has no spatial types; radius and nearest queries filter on the
+-- latitude/longitude index. The version exists to keep numbering aligned.
@@ -1,7 +1,11 @@
 package mock
 
 import (
+	"context"
+	"time"
+
 	"github.com/stretchr/testify/mock"
+	"github.com/yusufbulac/location-routing-service/internal/geo"
 	"github.com/yusufbulac/location-routing-service/internal/model"
 )
 
@@ -10,27 +14,90 @@ type MockLocationRepository struct {
 	mock.Mock
 }
 
-func (m *MockLocationRepository) Create(location *model.Location) error {
-	args := m.Called(location)
+func (m *MockLocationRepository) Create(ctx context.Context, location *model.Location) error {
+	args := m.Called(ctx, location)
+	return args.Error(0)
+}
+
+func (m *MockLocationRepository) CreateBatch(ctx context.Context, locations []model.Location) error {
+	args := m.Called(ctx, locations)
 	return args.Error(0)
 }
 
-func (m *MockLocationRepository) FindAll() ([]model.Location, error) {
-	args := m.Called()
+func (m *MockLocationRepository) FindAll(ctx context.Context) ([]model.Location, error) {
+	args := m.Called(ctx)
 	return args.Get(0).([]model.Location), args.Error(1)
 }
 
-func (m *MockLocationRepository) FindByID(id uint) (*model.Location, error) {
-	args := m.Called(id)
+// FindInBatches passes the locations returned by the expectation to fn in
+// batches of size.
+func (m *MockLocationRepository) FindInBatches(ctx context.Context, size int, fn func([]model.Location) error) error {
+	args := m.Called(ctx, size)
+	locations := args.Get(0).([]model.Location)
+	for start := 0; start < len(locations); start += size {
+		if err := fn(locations[start:min(start+size, len(locations))]); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockLocationRepository) FindByID(ctx context.Context, id uint) (*model.Location, error) {
+	args := m.Called(ctx, id)
 	return args.Get(0).(*model.Location), args.Error(1)
 }
 
-func (m *MockLocationRepository) Update(location *model.Location) error {
-	args := m.Called(location)
+func (m *MockLocationRepository) FindByIDs(ctx context.Context, ids []uint) ([]model.Location, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]model.Location), args.Error(1)
+}
+
+func (m *MockLocationRepository) FindByColors(ctx context.Context, colors []string) ([]model.Location, error) {
+	args := m.Called(ctx, colors)
+	return args.Get(0).([]model.Location), args.Error(1)
+}
+
+func (m *MockLocationRepository) Update(ctx context.Context, location *model.Location) error {
+	args := m.Called(ctx, location)
 	return args.Error(0)
 }
 
-func (m *MockLocationRepository) GetPaginatedLocations(limit int, offset int) ([]model.Location, error) {
-	args := m.Called(limit, offset)
+func (m *MockLocationRepository) GetPaginatedLocations(ctx context.Context, limit int, offset int) ([]model.Location, error) {
+	args := m.Called(ctx, limit, offset)
+	return args.Get(0).([]model.Location), args.Error(1)
+}
+
+func (m *MockLocationRepository) GetPaginatedLocationsInBox(ctx context.Context, box geo.BoundingBox, limit int, offset int) ([]model.Location, error) {
+	args := m.Called(ctx, box, limit, offset)
 	return args.Get(0).([]model.Location), args.Error(1)
 }
+
+func (m *MockLocationRepository) Delete(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockLocationRepository) Restore(ctx context.Context, id uint) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockLocationRepository) DataVersion(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLocationRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLocationRepository) FindWithinRadius(ctx context.Context, lat, lng, radiusKm float64, limit, offset int) ([]model.NearbyLocation, error) {
+	args := m.Called(ctx, lat, lng, radiusKm, limit, offset)
+	return args.Get(0).([]model.NearbyLocation), args.Error(1)
+}
+
+func (m *MockLocationRepository) FindNearest(ctx context.Context, lat, lng float64, k int, color string) ([]model.NearbyLocation, error) {
+	args := m.Called(ctx, lat, lng, k, color)
+	return args.Get(0).([]model.NearbyLocation), args.Error(1)
+}
@@ -0,0 +1,20 @@
+package model
+
+// MatrixPoint is an origin or destination of a distance matrix: a stored
+// location when LocationID is set, otherwise the point (Latitude, Longitude).
+type MatrixPoint struct {
+	LocationID uint    `json:"location_id,omitempty"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+}
+
+// DistanceMatrix holds the great-circle distance in kilometres and the
+// estimated duration in seconds from every origin (row) to every destination
+// (column).
+type DistanceMatrix struct {
+	Origins      []MatrixPoint `json:"origins"`
+	Destinations []MatrixPoint `json:"destinations"`
+	Distances    [][]float64   `json:"distances_km"`
+	Durations    [][]float64   `json:"durations_s"`
+	SpeedKmh     float64       `json:"speed_kmh"`
+}
@@ -1,14 +1,19 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Location struct {
 	ID        uint    `gorm:"primaryKey" json:"id"`
 	Name      string  `gorm:"type:varchar(100);not null" json:"name"`
-	Latitude  float64 `gorm:"not null" json:"latitude"`
-	Longitude float64 `gorm:"not null" json:"longitude"`
+	Latitude  float64 `gorm:"not null;index:idx_locations_lat_lng,priority:1" json:"latitude"`
+	Longitude float64 `gorm:"not null;index:idx_locations_lat_lng,priority:2" json:"longitude"`
 	Color     string  `gorm:"type:char(7);not null" json:"color"`
 
-	CreatedAt time.Time `json:"created_at" gorm:"<-:create"`
-	UpdatedAt time.Time `json:"updated_at"`
+	CreatedAt time.Time      `json:"created_at" gorm:"<-:create"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
@@ -0,0 +1,7 @@
+package model
+
+// NearbyLocation is a location annotated with its distance from a query point.
+type NearbyLocation struct {
+	Location
+	Distance float64 `json:"distance_km"`
+}
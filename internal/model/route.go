@@ -0,0 +1,28 @@
+package model
+
+// Route is an ordered visiting sequence of locations produced by the route optimiser.
+// All distances are in kilometres.
+// Routes anchored at a location carry it as Start; routes from a reference
+// point have none.
+type Route struct {
+	Start         *Location   `json:"start,omitempty"`
+	Stops         []RouteStop `json:"stops"`
+	TotalDistance float64     `json:"total_distance_km"`
+}
+
+// RouteStop is a single stop of a route together with the leg that leads to it.
+type RouteStop struct {
+	Location           Location `json:"location"`
+	LegDistance        float64  `json:"leg_distance_km"`
+	CumulativeDistance float64  `json:"cumulative_distance_km"`
+	Bearing            float64  `json:"bearing"`
+}
+
+// Locations returns the route's locations in visiting order.
+func (r *Route) Locations() []Location {
+	locations := make([]Location, 0, len(r.Stops))
+	for _, stop := range r.Stops {
+		locations = append(locations, stop.Location)
+	}
+	return locations
+}
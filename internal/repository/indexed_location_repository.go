@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/yusufbulac/location-routing-service/internal/geo"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+	"github.com/yusufbulac/location-routing-service/internal/spatial"
+)
+
+// staleVersion marks an index that must be reloaded before its next read.
+const staleVersion = -1
+
+// indexedLocationRepository serves spatial reads from an in-memory index and
+// delegates everything else to the wrapped repository, updating the index
+// after each successful write. Soft-deleted rows never enter the index, so
+// purging needs no bookkeeping. Every read first compares the index with the
+// database's data version and reloads it after writes made by other
+// processes, such as other replicas or the command-line tools.
+type indexedLocationRepository struct {
+	LocationRepository
+	index *spatial.Index
+
+	// mu serialises writes and reloads, so version always describes the
+	// contents of index.
+	mu      sync.Mutex
+	version int64
+}
+
+// NewIndexedLocationRepository loads every location from base into index and
+// returns a repository that keeps the index in sync with writes.
+func NewIndexedLocationRepository(ctx context.Context, base LocationRepository, index *spatial.Index) (LocationRepository, error) {
+	r := &indexedLocationRepository{LocationRepository: base, index: index}
+	if err := r.reload(ctx); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *indexedLocationRepository) Create(ctx context.Context, location *model.Location) error {
+	return r.write(ctx, func() error {
+		if err := r.LocationRepository.Create(ctx, location); err != nil {
+			return err
+		}
+		r.index.Upsert(*location)
+		return nil
+	})
+}
+
+func (r *indexedLocationRepository) CreateBatch(ctx context.Context, locations []model.Location) error {
+	return r.write(ctx, func() error {
+		if err := r.LocationRepository.CreateBatch(ctx, locations); err != nil {
+			return err
+		}
+		for _, location := range locations {
+			r.index.Upsert(location)
+		}
+		return nil
+	})
+}
+
+func (r *indexedLocationRepository) Update(ctx context.Context, location *model.Location) error {
+	return r.write(ctx, func() error {
+		if err := r.LocationRepository.Update(ctx, location); err != nil {
+			return err
+		}
+		r.index.Upsert(*location)
+		return nil
+	})
+}
+
+func (r *indexedLocationRepository) Delete(ctx context.Context, id uint) error {
+	return r.write(ctx, func() error {
+		if err := r.LocationRepository.Delete(ctx, id); err != nil {
+			return err
+		}
+		r.index.Remove(id)
+		return nil
+	})
+}
+
+func (r *indexedLocationRepository) Restore(ctx context.Context, id uint) error {
+	return r.write(ctx, func() error {
+		if err := r.LocationRepository.Restore(ctx, id); err != nil {
+			return err
+		}
+		location, err := r.LocationRepository.FindByID(ctx, id)
+		if err != nil {
+			// the row is restored but could not be read back
+			r.version = staleVersion
+			return err
+		}
+		r.index.Upsert(*location)
+		return nil
+	})
+}
+
+func (r *indexedLocationRepository) FindAll(ctx context.Context) ([]model.Location, error) {
+	if err := r.sync(ctx); err != nil {
+		return nil, err
+	}
+	return r.index.All(), nil
+}
+
+func (r *indexedLocationRepository) GetPaginatedLocationsInBox(ctx context.Context, box geo.BoundingBox, limit, offset int) ([]model.Location, error) {
+	if err := r.sync(ctx); err != nil {
+		return nil, err
+	}
+	return r.index.InBox(box, limit, offset), nil
+}
+
+func (r *indexedLocationRepository) FindWithinRadius(ctx context.Context, lat, lng, radiusKm float64, limit, offset int) ([]model.NearbyLocation, error) {
+	if err := r.sync(ctx); err != nil {
+		return nil, err
+	}
+	return r.index.WithinRadius(lat, lng, radiusKm, limit, offset), nil
+}
+
+func (r *indexedLocationRepository) FindNearest(ctx context.Context, lat, lng float64, k int, color string) ([]model.NearbyLocation, error) {
+	if err := r.sync(ctx); err != nil {
+		return nil, err
+	}
+	return r.index.Nearest(lat, lng, k, color), nil
+}
+
+// write runs a write that also applies itself to the index. The index stays
+// current when the write was the only change to the data version; a write
+// by another process in between makes the next read reload it.
+func (r *indexedLocationRepository) write(ctx context.Context, fn func() error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	before := r.version
+	if err := fn(); err != nil {
+		return err
+	}
+	after, err := r.LocationRepository.DataVersion(ctx)
+	if err != nil || before == staleVersion || after != before+1 {
+		r.version = staleVersion
+		return nil
+	}
+	r.version = after
+	return nil
+}
+
+// sync reloads the index if the data version changed since it was loaded.
+func (r *indexedLocationRepository) sync(ctx context.Context) error {
+	version, err := r.LocationRepository.DataVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if version == r.version {
+		return nil
+	}
+	return r.reload(ctx)
+}
+
+// reload replaces the index with every location. The version is read first,
+// so a concurrent write makes the next sync reload again rather than be missed.
+func (r *indexedLocationRepository) reload(ctx context.Context) error {
+	version, err := r.LocationRepository.DataVersion(ctx)
+	if err != nil {
+		return err
+	}
+	locations, err := r.LocationRepository.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+	r.index.Load(locations)
+	r.version = version
+	return nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+	"github.com/yusufbulac/location-routing-service/internal/spatial"
+)
+
+// countingRepository counts the full-table reads that rebuild the index.
+type countingRepository struct {
+	LocationRepository
+	loads int
+}
+
+func (r *countingRepository) FindAll(ctx context.Context) ([]model.Location, error) {
+	r.loads++
+	return r.LocationRepository.FindAll(ctx)
+}
+
+func TestIndexedLocationRepository_KeepsIndexInSync(t *testing.T) {
+	ctx := context.Background()
+	base := &countingRepository{LocationRepository: NewLocationRepository(openSQLite(t))}
+	require.NoError(t, base.Create(ctx, &model.Location{Name: "A", Latitude: 41, Longitude: 29, Color: "#ff0000"}))
+
+	index := spatial.NewIndex(spatial.DefaultCellSize)
+	repo, err := NewIndexedLocationRepository(ctx, base, index)
+	require.NoError(t, err)
+	assert.Equal(t, 1, index.Len())
+
+	created := &model.Location{Name: "B", Latitude: 41.01, Longitude: 29.01, Color: "#00ff00"}
+	require.NoError(t, repo.Create(ctx, created))
+
+	nearest, err := repo.FindNearest(ctx, 41.01, 29.01, 1, "")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, nearest[0].ID)
+
+	created.Latitude, created.Longitude = 10, 10
+	require.NoError(t, repo.Update(ctx, created))
+
+	nearby, err := repo.FindWithinRadius(ctx, 41, 29, 5, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, nearby, 1)
+
+	require.NoError(t, repo.Delete(ctx, 1))
+	all, err := repo.FindAll(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, created.ID, all[0].ID)
+
+	require.NoError(t, repo.Restore(ctx, 1))
+	assert.Equal(t, 2, index.Len())
+
+	_, err = repo.FindAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, base.loads, "own writes update the index without reloading it")
+}
+
+func TestIndexedLocationRepository_ReloadsAfterWritesByOtherProcesses(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	other := NewLocationRepository(db)
+	base := &countingRepository{LocationRepository: NewLocationRepository(db)}
+
+	repo, err := NewIndexedLocationRepository(ctx, base, spatial.NewIndex(spatial.DefaultCellSize))
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(ctx, &model.Location{Name: "Own", Latitude: 41, Longitude: 29, Color: "#ff0000"}))
+
+	require.NoError(t, other.CreateBatch(ctx, []model.Location{
+		{Name: "Imported", Latitude: 41.001, Longitude: 29.001, Color: "#00ff00"},
+	}))
+
+	nearby, err := repo.FindWithinRadius(ctx, 41, 29, 5, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, nearby, 2, "the index sees locations written by another process")
+	assert.Equal(t, 2, base.loads)
+
+	require.NoError(t, other.Delete(ctx, nearby[0].ID))
+	all, err := repo.FindAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+
+	// a write by another process between two of our own is not mistaken for ours
+	require.NoError(t, other.Create(ctx, &model.Location{Name: "Other", Latitude: 0, Longitude: 0, Color: "#0000ff"}))
+	require.NoError(t, repo.Create(ctx, &model.Location{Name: "Own 2", Latitude: 1, Longitude: 1, Color: "#ff0000"}))
+	all, err = repo.FindAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+}
@@ -1,54 +1,328 @@
 package repository
 
 import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yusufbulac/location-routing-service/internal/geo"
 	"github.com/yusufbulac/location-routing-service/internal/model"
 
 	"gorm.io/gorm"
 )
 
+const (
+	// createBatchSize is the number of rows inserted per statement by CreateBatch.
+	createBatchSize = 500
+	// findByIDsChunk is the number of IDs bound per query by FindByIDs, well
+	// below the bind parameter limits of every supported database.
+	findByIDsChunk = 500
+)
+
 type LocationRepository interface {
-	Create(location *model.Location) error
-	FindAll() ([]model.Location, error)
-	FindByID(id uint) (*model.Location, error)
-	Update(location *model.Location) error
-	GetPaginatedLocations(limit, offset int) ([]model.Location, error)
+	Create(ctx context.Context, location *model.Location) error
+	CreateBatch(ctx context.Context, locations []model.Location) error
+	FindAll(ctx context.Context) ([]model.Location, error)
+	FindInBatches(ctx context.Context, size int, fn func([]model.Location) error) error
+	FindByID(ctx context.Context, id uint) (*model.Location, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]model.Location, error)
+	FindByColors(ctx context.Context, colors []string) ([]model.Location, error)
+	Update(ctx context.Context, location *model.Location) error
+	GetPaginatedLocations(ctx context.Context, limit, offset int) ([]model.Location, error)
+	GetPaginatedLocationsInBox(ctx context.Context, box geo.BoundingBox, limit, offset int) ([]model.Location, error)
+	Delete(ctx context.Context, id uint) error
+	Restore(ctx context.Context, id uint) error
+	DataVersion(ctx context.Context) (int64, error)
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+	FindWithinRadius(ctx context.Context, lat, lng, radiusKm float64, limit, offset int) ([]model.NearbyLocation, error)
+	FindNearest(ctx context.Context, lat, lng float64, k int, color string) ([]model.NearbyLocation, error)
 }
 
 type locationRepository struct {
 	db *gorm.DB
 }
 
+// NewLocationRepository returns the repository for db. On MySQL and Postgres,
+// spatial queries use the indexed `position` column; on SQLite, distances are
+// computed, sorted and paged in SQL.
 func NewLocationRepository(db *gorm.DB) LocationRepository {
-	return &locationRepository{db: db}
+	base := &locationRepository{db: db}
+	switch db.Dialector.Name() {
+	case "mysql":
+		return &mysqlLocationRepository{locationRepository: base}
+	case "postgres":
+		return &postgresLocationRepository{locationRepository: base}
+	case "sqlite":
+		return &sqliteLocationRepository{locationRepository: base}
+	default:
+		return base
+	}
 }
 
-func (r *locationRepository) Create(location *model.Location) error {
-	return r.db.Create(location).Error
+func (r *locationRepository) Create(ctx context.Context, location *model.Location) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(location).Error; err != nil {
+			return err
+		}
+		return bumpDataVersion(tx)
+	})
 }
 
-func (r *locationRepository) FindAll() ([]model.Location, error) {
+// CreateBatch inserts locations in a single transaction and sets their IDs.
+// Either every location is stored or none is.
+func (r *locationRepository) CreateBatch(ctx context.Context, locations []model.Location) error {
+	if len(locations) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(locations, createBatchSize).Error; err != nil {
+			return err
+		}
+		return bumpDataVersion(tx)
+	})
+}
+
+func (r *locationRepository) FindAll(ctx context.Context) ([]model.Location, error) {
 	var locations []model.Location
-	err := r.db.Find(&locations).Error
+	err := r.db.WithContext(ctx).Find(&locations).Error
 	return locations, err
 }
 
-func (r *locationRepository) FindByID(id uint) (*model.Location, error) {
+// FindInBatches calls fn with successive batches of at most size locations in
+// ID order, reading one batch at a time. An error from fn stops the iteration
+// and is returned.
+func (r *locationRepository) FindInBatches(ctx context.Context, size int, fn func([]model.Location) error) error {
+	var batch []model.Location
+	return r.db.WithContext(ctx).FindInBatches(&batch, size, func(_ *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
+func (r *locationRepository) FindByID(ctx context.Context, id uint) (*model.Location, error) {
 	var location model.Location
-	err := r.db.First(&location, id).Error
+	err := r.db.WithContext(ctx).First(&location, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &location, nil
 }
 
-func (r *locationRepository) Update(location *model.Location) error {
-	return r.db.Save(location).Error
+// FindByIDs returns the live locations with the given IDs in ID order. IDs
+// without a live location are left out.
+func (r *locationRepository) FindByIDs(ctx context.Context, ids []uint) ([]model.Location, error) {
+	locations := make([]model.Location, 0, len(ids))
+	for start := 0; start < len(ids); start += findByIDsChunk {
+		var chunk []model.Location
+		err := r.db.WithContext(ctx).Where("id IN ?", ids[start:min(start+findByIDsChunk, len(ids))]).Find(&chunk).Error
+		if err != nil {
+			return nil, err
+		}
+		locations = append(locations, chunk...)
+	}
+	sort.Slice(locations, func(i, j int) bool { return locations[i].ID < locations[j].ID })
+	return locations, nil
+}
+
+// FindByColors returns the live locations with any of the given marker
+// colors, compared case-insensitively, in ID order.
+func (r *locationRepository) FindByColors(ctx context.Context, colors []string) ([]model.Location, error) {
+	locations := []model.Location{}
+	if len(colors) == 0 {
+		return locations, nil
+	}
+	lowered := make([]string, len(colors))
+	for i, color := range colors {
+		lowered[i] = strings.ToLower(color)
+	}
+	err := r.db.WithContext(ctx).Where("LOWER(color) IN ?", lowered).Order("id").Find(&locations).Error
+	return locations, err
+}
+
+func (r *locationRepository) Update(ctx context.Context, location *model.Location) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(location).Error; err != nil {
+			return err
+		}
+		return bumpDataVersion(tx)
+	})
+}
+
+func (r *locationRepository) GetPaginatedLocations(ctx context.Context, limit, offset int) ([]model.Location, error) {
+	var locations []model.Location
+	if err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Order("id").Find(&locations).Error; err != nil {
+		return nil, err
+	}
+	return locations, nil
 }
 
-func (r *locationRepository) GetPaginatedLocations(limit, offset int) ([]model.Location, error) {
+// GetPaginatedLocationsInBox pages through the locations inside box in ID order.
+func (r *locationRepository) GetPaginatedLocationsInBox(ctx context.Context, box geo.BoundingBox, limit, offset int) ([]model.Location, error) {
 	var locations []model.Location
-	if err := r.db.Limit(limit).Offset(offset).Order("id").Find(&locations).Error; err != nil {
+	if err := withinBoundingBox(r.db.WithContext(ctx), box).Limit(limit).Offset(offset).Order("id").Find(&locations).Error; err != nil {
 		return nil, err
 	}
 	return locations, nil
 }
+
+// Delete soft-deletes the location. It returns gorm.ErrRecordNotFound if no
+// live location has the given ID.
+func (r *locationRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&model.Location{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return bumpDataVersion(tx)
+	})
+}
+
+// Restore clears the soft-delete marker of the location. It returns
+// gorm.ErrRecordNotFound if no soft-deleted location has the given ID.
+func (r *locationRepository) Restore(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&model.Location{}).
+			Where("id = ? AND deleted_at IS NOT NULL", id).
+			Update("deleted_at", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return bumpDataVersion(tx)
+	})
+}
+
+// DataVersion returns a counter of the writes to live locations, shared by
+// every process using the database. Purging soft-deleted rows leaves it
+// unchanged.
+func (r *locationRepository) DataVersion(ctx context.Context) (int64, error) {
+	var version int64
+	err := r.db.WithContext(ctx).Raw("SELECT version FROM dataset_version WHERE id = 1").Scan(&version).Error
+	return version, err
+}
+
+// bumpDataVersion increments the data version within the transaction of a write.
+func bumpDataVersion(tx *gorm.DB) error {
+	return tx.Exec("UPDATE dataset_version SET version = version + 1 WHERE id = 1").Error
+}
+
+// PurgeDeleted permanently removes locations soft-deleted before the given time.
+func (r *locationRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Delete(&model.Location{})
+	return result.RowsAffected, result.Error
+}
+
+// FindWithinRadius returns locations within radiusKm of the given point ordered
+// by distance. Candidates are narrowed down in SQL with a bounding box before
+// exact great-circle distances are computed and the page is cut; dialects with
+// distance functions override it to sort and page in SQL.
+func (r *locationRepository) FindWithinRadius(ctx context.Context, lat, lng, radiusKm float64, limit, offset int) ([]model.NearbyLocation, error) {
+	nearby, err := r.findWithinRadius(r.db.WithContext(ctx), lat, lng, radiusKm)
+	if err != nil {
+		return nil, err
+	}
+	return geo.Paginate(nearby, limit, offset), nil
+}
+
+// FindNearest returns the k locations closest to the given point, optionally
+// restricted to a marker color. It probes growing bounding boxes until k
+// locations lie within the probed radius, which guarantees no closer location
+// exists outside the box, so only a neighbourhood of the point is loaded.
+func (r *locationRepository) FindNearest(ctx context.Context, lat, lng float64, k int, color string) ([]model.NearbyLocation, error) {
+	query := withColor(r.db.WithContext(ctx), color)
+	return geo.SearchNearest(k, func(radiusKm float64) ([]model.NearbyLocation, error) {
+		return r.findWithinRadius(query, lat, lng, radiusKm)
+	})
+}
+
+// findWithinRadius loads the rows inside the bounding box of the circle and
+// returns those within radiusKm, ordered by distance and then ID.
+func (r *locationRepository) findWithinRadius(db *gorm.DB, lat, lng, radiusKm float64) ([]model.NearbyLocation, error) {
+	var candidates []model.Location
+	box := geo.BoundingBoxAround(lat, lng, radiusKm)
+	if err := withinBoundingBox(db, box).Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	nearby := make([]model.NearbyLocation, 0, len(candidates))
+	for _, loc := range candidates {
+		d := geo.Haversine(lat, lng, loc.Latitude, loc.Longitude)
+		if d <= radiusKm {
+			nearby = append(nearby, model.NearbyLocation{Location: loc, Distance: d})
+		}
+	}
+
+	sort.Slice(nearby, func(i, j int) bool {
+		if nearby[i].Distance != nearby[j].Distance {
+			return nearby[i].Distance < nearby[j].Distance
+		}
+		return nearby[i].ID < nearby[j].ID
+	})
+	return nearby, nil
+}
+
+// withColor restricts db to locations with the given marker color, compared
+// case-insensitively, unless color is empty. A new session keeps the condition
+// reusable, such as across the probes of a nearest search.
+func withColor(db *gorm.DB, color string) *gorm.DB {
+	if color == "" {
+		return db
+	}
+	return db.Where("LOWER(color) = ?", strings.ToLower(color)).Session(&gorm.Session{})
+}
+
+// sqlDistance lets a dialect compute, sort and page great-circle distances in
+// SQL. Dialects supply the distance expression and the prefilter narrowing the
+// rows to the bounding box of a search.
+type sqlDistance struct {
+	// expr returns the SQL distance in kilometres from the point to a row and
+	// its arguments.
+	expr func(lat, lng float64) (string, []interface{})
+	// prefilter restricts a query to rows inside box, ideally through an index.
+	prefilter func(db *gorm.DB, box geo.BoundingBox) *gorm.DB
+}
+
+// withinRadius returns the page of locations within radiusKm of the point,
+// ordered by distance and then ID.
+func (d sqlDistance) withinRadius(db *gorm.DB, lat, lng, radiusKm float64, limit, offset int) ([]model.NearbyLocation, error) {
+	box := geo.BoundingBoxAround(lat, lng, radiusKm)
+	distance, args := d.expr(lat, lng)
+
+	nearby := []model.NearbyLocation{}
+	err := d.prefilter(db.Model(&model.Location{}), box).
+		Select("locations.*, "+distance+" AS distance", args...).
+		Where(distance+" <= ?", append(args, radiusKm)...).
+		Order("distance, id").
+		Limit(limit).
+		Offset(offset).
+		Find(&nearby).Error
+	if err != nil {
+		return nil, err
+	}
+	return nearby, nil
+}
+
+// nearest probes growing radii like the generic FindNearest, letting the
+// database return at most k rows per probe.
+func (d sqlDistance) nearest(db *gorm.DB, lat, lng float64, k int, color string) ([]model.NearbyLocation, error) {
+	query := withColor(db, color)
+	return geo.SearchNearest(k, func(radiusKm float64) ([]model.NearbyLocation, error) {
+		return d.withinRadius(query, lat, lng, radiusKm, k, 0)
+	})
+}
+
+// withinBoundingBox restricts a query to rows whose coordinates fall inside box.
+func withinBoundingBox(db *gorm.DB, box geo.BoundingBox) *gorm.DB {
+	db = db.Where("latitude BETWEEN ? AND ?", box.MinLat, box.MaxLat)
+	if box.CrossesAntimeridian() {
+		return db.Where("(longitude >= ? OR longitude <= ?)", box.MinLng, box.MaxLng)
+	}
+	return db.Where("longitude BETWEEN ? AND ?", box.MinLng, box.MaxLng)
+}
@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yusufbulac/location-routing-service/internal/geo"
+	"github.com/yusufbulac/location-routing-service/internal/migrations"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+	"github.com/yusufbulac/location-routing-service/internal/spatial"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// contractBackends open an empty, migrated locations table for every
+// LocationRepository implementation. Server databases are only tested when
+// TEST_MYSQL_DSN or TEST_POSTGRES_DSN is set; their locations table is wiped.
+var contractBackends = map[string]func(t *testing.T) LocationRepository{
+	"sqlite": func(t *testing.T) LocationRepository {
+		return NewLocationRepository(openSQLite(t))
+	},
+	"sqlite+index": func(t *testing.T) LocationRepository {
+		repo, err := NewIndexedLocationRepository(context.Background(), NewLocationRepository(openSQLite(t)), spatial.NewIndex(spatial.DefaultCellSize))
+		require.NoError(t, err)
+		return repo
+	},
+	"mysql": func(t *testing.T) LocationRepository {
+		return NewLocationRepository(openServer(t, "TEST_MYSQL_DSN", mysql.Open))
+	},
+	"postgres": func(t *testing.T) LocationRepository {
+		return NewLocationRepository(openServer(t, "TEST_POSTGRES_DSN", postgres.Open))
+	},
+}
+
+func openSQLite(t *testing.T) *gorm.DB {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "contract.db")
+	db, err := gorm.Open(sqlite.Open("file:"+path), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	migrateUp(t, db)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func openServer(t *testing.T, env string, open func(string) gorm.Dialector) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv(env)
+	if dsn == "" {
+		t.Skipf("%s not set", env)
+	}
+	db, err := gorm.Open(open(dsn), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	migrateUp(t, db)
+	require.NoError(t, db.Exec("DELETE FROM locations").Error)
+	return db
+}
+
+func migrateUp(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	migrator, err := migrations.New(db)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+}
+
+// contractLocations spread over both sides of the antimeridian and several colors.
+var contractLocations = []model.Location{
+	{Name: "Istanbul", Latitude: 41.0082, Longitude: 28.9784, Color: "#FF0000"},
+	{Name: "Ankara", Latitude: 39.9334, Longitude: 32.8597, Color: "#00ff00"},
+	{Name: "Bursa", Latitude: 40.1885, Longitude: 29.0610, Color: "#ff0000"},
+	{Name: "Izmit", Latitude: 40.7654, Longitude: 29.9408, Color: "#0000ff"},
+	{Name: "Suva", Latitude: -18.1416, Longitude: 178.4419, Color: "#ff0000"},
+	{Name: "Apia", Latitude: -13.8506, Longitude: -171.7513, Color: "#00ff00"},
+}
+
+func seed(t *testing.T, ctx context.Context, repo LocationRepository) []model.Location {
+	t.Helper()
+	seeded := make([]model.Location, len(contractLocations))
+	for i, loc := range contractLocations {
+		loc := loc
+		require.NoError(t, repo.Create(ctx, &loc))
+		require.NotZero(t, loc.ID)
+		seeded[i] = loc
+	}
+	return seeded
+}
+
+func names(locations []model.Location) []string {
+	out := make([]string, len(locations))
+	for i, loc := range locations {
+		out[i] = loc.Name
+	}
+	return out
+}
+
+func nearbyNames(nearby []model.NearbyLocation) []string {
+	out := make([]string, len(nearby))
+	for i, loc := range nearby {
+		out[i] = loc.Name
+	}
+	return out
+}
+
+func TestLocationRepositoryContract(t *testing.T) {
+	for name, open := range contractBackends {
+		open := open
+		t.Run(name, func(t *testing.T) {
+			t.Run("CRUD", func(t *testing.T) { testContractCRUD(t, open(t)) })
+			t.Run("CreateBatch", func(t *testing.T) { testContractCreateBatch(t, open(t)) })
+			t.Run("FindInBatches", func(t *testing.T) { testContractFindInBatches(t, open(t)) })
+			t.Run("FindByIDsAndColors", func(t *testing.T) { testContractFindByIDsAndColors(t, open(t)) })
+			t.Run("SoftDelete", func(t *testing.T) { testContractSoftDelete(t, open(t)) })
+			t.Run("Pagination", func(t *testing.T) { testContractPagination(t, open(t)) })
+			t.Run("WithinRadius", func(t *testing.T) { testContractWithinRadius(t, open(t)) })
+			t.Run("Nearest", func(t *testing.T) { testContractNearest(t, open(t)) })
+			t.Run("DataVersion", func(t *testing.T) { testContractDataVersion(t, open(t)) })
+		})
+	}
+}
+
+func testContractCRUD(t *testing.T, repo LocationRepository) {
+	ctx := context.Background()
+	seeded := seed(t, ctx, repo)
+
+	found, err := repo.FindByID(ctx, seeded[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Istanbul", found.Name)
+
+	found.Name = "Constantinople"
+	found.Latitude = 41.01
+	require.NoError(t, repo.Update(ctx, found))
+
+	all, err := repo.FindAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, len(contractLocations))
+
+	nearest, err := repo.FindNearest(ctx, 41.01, 28.9784, 1, "")
+	require.NoError(t, err)
+	require.Len(t, nearest, 1)
+	assert.Equal(t, "Constantinople", nearest[0].Name)
+
+	_, err = repo.FindByID(ctx, seeded[len(seeded)-1].ID+100)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func testContractCreateBatch(t *testing.T, repo LocationRepository) {
+	ctx := context.Background()
+	batch := append([]model.Location(nil), contractLocations...)
+	require.NoError(t, repo.CreateBatch(ctx, batch))
+	for _, loc := range batch {
+		assert.NotZero(t, loc.ID)
+	}
+
+	nearest, err := repo.FindNearest(ctx, 41.0082, 28.9784, 1, "")
+	require.NoError(t, err)
+	require.Len(t, nearest, 1)
+	assert.Equal(t, batch[0].ID, nearest[0].ID, "batch rows are visible to spatial queries")
+
+	all, err := repo.FindAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, len(contractLocations))
+	require.NoError(t, repo.CreateBatch(ctx, nil))
+}
+
+func testContractFindInBatches(t *testing.T, repo LocationRepository) {
+	ctx := context.Background()
+	seeded := seed(t, ctx, repo)
+	require.NoError(t, repo.Delete(ctx, seeded[1].ID))
+
+	var batches [][]string
+	err := repo.FindInBatches(ctx, 2, func(batch []model.Location) error {
+		batches = append(batches, names(batch))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"Istanbul", "Bursa"}, {"Izmit", "Suva"}, {"Apia"}}, batches, "deleted locations are skipped")
+
+	stop := errors.New("stop")
+	calls := 0
+	err = repo.FindInBatches(ctx, 2, func([]model.Location) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
+
+func testContractFindByIDsAndColors(t *testing.T, repo LocationRepository) {
+	ctx := context.Background()
+	seeded := seed(t, ctx, repo)
+	require.NoError(t, repo.Delete(ctx, seeded[2].ID))
+
+	found, err := repo.FindByIDs(ctx, []uint{seeded[4].ID, seeded[0].ID, seeded[2].ID, seeded[5].ID + 100})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Istanbul", "Suva"}, names(found), "missing and deleted IDs are left out")
+
+	many := make([]uint, 0, 2*findByIDsChunk+len(seeded))
+	for i := uint(1); len(many) < cap(many)-len(seeded); i++ {
+		many = append(many, seeded[5].ID+i)
+	}
+	for i := len(seeded) - 1; i >= 0; i-- {
+		many = append(many, seeded[i].ID)
+	}
+	found, err = repo.FindByIDs(ctx, many)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Istanbul", "Ankara", "Izmit", "Suva", "Apia"}, names(found), "IDs spanning several queries come back in ID order")
+
+	found, err = repo.FindByIDs(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	found, err = repo.FindByColors(ctx, []string{"#ff0000", "#0000FF"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Istanbul", "Izmit", "Suva"}, names(found))
+}
+
+func testContractDataVersion(t *testing.T, repo LocationRepository) {
+	ctx := context.Background()
+	version := func() int64 {
+		v, err := repo.DataVersion(ctx)
+		require.NoError(t, err)
+		return v
+	}
+
+	start := version()
+	seeded := seed(t, ctx, repo)
+	afterSeed := version()
+	assert.Greater(t, afterSeed, start, "creates bump the version")
+
+	seeded[0].Name = "Renamed"
+	require.NoError(t, repo.Update(ctx, &seeded[0]))
+	assert.Equal(t, afterSeed+1, version())
+	require.NoError(t, repo.Delete(ctx, seeded[0].ID))
+	assert.Equal(t, afterSeed+2, version())
+	require.NoError(t, repo.Restore(ctx, seeded[0].ID))
+	assert.Equal(t, afterSeed+3, version())
+
+	assert.ErrorIs(t, repo.Delete(ctx, 999999), gorm.ErrRecordNotFound)
+	assert.Equal(t, afterSeed+3, version(), "failed writes leave the version unchanged")
+}
+
+func testContractSoftDelete(t *testing.T, repo LocationRepository) {
+	ctx := context.Background()
+	seeded := seed(t, ctx, repo)
+	istanbul, ankara := seeded[0].ID, seeded[1].ID
+
+	require.NoError(t, repo.Delete(ctx, istanbul))
+	assert.ErrorIs(t, repo.Delete(ctx, istanbul), gorm.ErrRecordNotFound)
+	_, err := repo.FindByID(ctx, istanbul)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	nearby, err := repo.FindWithinRadius(ctx, 41.0082, 28.9784, 50, 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, nearby, "deleted locations are excluded from spatial queries")
+
+	assert.ErrorIs(t, repo.Restore(ctx, ankara), gorm.ErrRecordNotFound, "live locations cannot be restored")
+	require.NoError(t, repo.Restore(ctx, istanbul))
+	nearby, err = repo.FindWithinRadius(ctx, 41.0082, 28.9784, 50, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Istanbul"}, nearbyNames(nearby))
+
+	require.NoError(t, repo.Delete(ctx, istanbul))
+	require.NoError(t, repo.Delete(ctx, ankara))
+	purged, err := repo.PurgeDeleted(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, purged, "recently deleted locations are kept")
+
+	purged, err = repo.PurgeDeleted(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+	assert.ErrorIs(t, repo.Restore(ctx, istanbul), gorm.ErrRecordNotFound)
+}
+
+func testContractPagination(t *testing.T, repo LocationRepository) {
+	ctx := context.Background()
+	seed(t, ctx, repo)
+
+	page, err := repo.GetPaginatedLocations(ctx, 2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Ankara", "Bursa"}, names(page))
+
+	turkey := geo.BoundingBox{MinLat: 39, MinLng: 28, MaxLat: 42, MaxLng: 33}
+	inBox, err := repo.GetPaginatedLocationsInBox(ctx, turkey, 10, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Ankara", "Bursa", "Izmit"}, names(inBox))
+
+	pacific := geo.BoundingBox{MinLat: -20, MinLng: 170, MaxLat: -10, MaxLng: -170}
+	inBox, err = repo.GetPaginatedLocationsInBox(ctx, pacific, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Suva", "Apia"}, names(inBox))
+}
+
+func testContractWithinRadius(t *testing.T, repo LocationRepository) {
+	ctx := context.Background()
+	seed(t, ctx, repo)
+
+	nearby, err := repo.FindWithinRadius(ctx, 41.0082, 28.9784, 200, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Istanbul", "Izmit", "Bursa"}, nearbyNames(nearby))
+	for _, loc := range nearby {
+		assert.InDelta(t, geo.Haversine(41.0082, 28.9784, loc.Latitude, loc.Longitude), loc.Distance, 1e-6)
+	}
+
+	page, err := repo.FindWithinRadius(ctx, 41.0082, 28.9784, 200, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Izmit"}, nearbyNames(page))
+
+	// Suva and Apia are ~1150 km apart across the antimeridian
+	nearby, err = repo.FindWithinRadius(ctx, -16, 179.9, 1000, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Suva", "Apia"}, nearbyNames(nearby))
+}
+
+func testContractNearest(t *testing.T, repo LocationRepository) {
+	ctx := context.Background()
+	seed(t, ctx, repo)
+
+	nearest, err := repo.FindNearest(ctx, 40.9, 29.1, 2, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Istanbul", "Izmit"}, nearbyNames(nearest))
+
+	red, err := repo.FindNearest(ctx, 40.9, 29.1, 3, "#ff0000")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Istanbul", "Bursa", "Suva"}, nearbyNames(red), "color matches ignore case")
+	for _, loc := range red {
+		assert.InDelta(t, geo.Haversine(40.9, 29.1, loc.Latitude, loc.Longitude), loc.Distance, 1e-6)
+	}
+
+	all, err := repo.FindNearest(ctx, 0, 0, 100, "")
+	require.NoError(t, err)
+	assert.Len(t, all, len(contractLocations))
+}
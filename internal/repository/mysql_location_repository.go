@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/yusufbulac/location-routing-service/internal/geo"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// earthRadiusMeters matches geo.EarthRadiusKm so SQL and Go distances agree.
+	earthRadiusMeters = geo.EarthRadiusKm * 1000
+	// maxPolygonSpan is the widest longitude span of a single filter polygon;
+	// wider boxes are split so each polygon is unambiguous on the sphere.
+	maxPolygonSpan = 90.0
+	// polarLatitude is the latitude beyond which the spatial prefilter is skipped.
+	polarLatitude = 89.0
+
+	pointSQL    = "ST_SRID(POINT(?, ?), 4326)"
+	distanceSQL = "ST_Distance_Sphere(position, " + pointSQL + ", ?) / 1000"
+)
+
+// mysqlLocationRepository answers spatial queries with the SRID 4326 `position`
+// column and its SPATIAL index. MBRIntersects against the query box narrows the
+// rows through the index; exact distance or coordinate predicates then keep
+// results identical to the generic implementation.
+//
+// These queries serve every radius, nearest and viewport request on MySQL
+// unless SPATIAL_INDEX_ENABLED turns on the in-memory index, which then
+// answers them instead.
+type mysqlLocationRepository struct {
+	*locationRepository
+}
+
+func (r *mysqlLocationRepository) GetPaginatedLocationsInBox(ctx context.Context, box geo.BoundingBox, limit, offset int) ([]model.Location, error) {
+	var locations []model.Location
+	query := withinBoundingBox(withinSpatialBox(r.db.WithContext(ctx), box), box)
+	if err := query.Limit(limit).Offset(offset).Order("id").Find(&locations).Error; err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// mysqlDistance prefilters through the SPATIAL index and measures distances
+// with ST_Distance_Sphere.
+var mysqlDistance = sqlDistance{
+	expr: func(lat, lng float64) (string, []interface{}) {
+		return distanceSQL, []interface{}{lng, lat, earthRadiusMeters}
+	},
+	prefilter: withinSpatialBox,
+}
+
+func (r *mysqlLocationRepository) FindWithinRadius(ctx context.Context, lat, lng, radiusKm float64, limit, offset int) ([]model.NearbyLocation, error) {
+	return mysqlDistance.withinRadius(r.db.WithContext(ctx), lat, lng, radiusKm, limit, offset)
+}
+
+func (r *mysqlLocationRepository) FindNearest(ctx context.Context, lat, lng float64, k int, color string) ([]model.NearbyLocation, error) {
+	return mysqlDistance.nearest(r.db.WithContext(ctx), lat, lng, k, color)
+}
+
+// withinSpatialBox adds an index-backed MBRIntersects prefilter for box.
+// Boxes reaching a pole are left unfiltered.
+func withinSpatialBox(db *gorm.DB, box geo.BoundingBox) *gorm.DB {
+	if box.MinLat <= -polarLatitude || box.MaxLat >= polarLatitude {
+		return db
+	}
+
+	polygons := boxPolygons(box)
+	conditions := make([]string, len(polygons))
+	args := make([]interface{}, len(polygons))
+	for i, polygon := range polygons {
+		conditions[i] = "MBRIntersects(ST_GeomFromText(?, 4326, 'axis-order=long-lat'), position)"
+		args[i] = polygon
+	}
+	return db.Where("("+strings.Join(conditions, " OR ")+")", args...)
+}
+
+// boxPolygons returns WKT polygons in long-lat order covering box, splitting
+// it at the antimeridian and into pieces at most maxPolygonSpan degrees wide.
+func boxPolygons(box geo.BoundingBox) []string {
+	type span struct{ from, to float64 }
+	spans := []span{{box.MinLng, box.MaxLng}}
+	if box.CrossesAntimeridian() {
+		spans = []span{{box.MinLng, 180}, {-180, box.MaxLng}}
+	}
+
+	minLat, maxLat := box.MinLat, box.MaxLat
+	if maxLat-minLat < 1e-9 {
+		maxLat = minLat + 1e-9
+	}
+
+	var polygons []string
+	for _, s := range spans {
+		for from := s.from; ; from += maxPolygonSpan {
+			to := math.Min(from+maxPolygonSpan, s.to)
+			if to-from < 1e-9 {
+				// widen degenerate boxes without leaving the valid longitude range
+				if to+1e-9 <= 180 {
+					to += 1e-9
+				} else {
+					from -= 1e-9
+				}
+			}
+			polygons = append(polygons, fmt.Sprintf(
+				"POLYGON((%[1]s %[3]s, %[2]s %[3]s, %[2]s %[4]s, %[1]s %[4]s, %[1]s %[3]s))",
+				wktNumber(from), wktNumber(to), wktNumber(minLat), wktNumber(maxLat),
+			))
+			if to >= s.to {
+				break
+			}
+		}
+	}
+	return polygons
+}
+
+func wktNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yusufbulac/location-routing-service/internal/geo"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestBoxPolygons(t *testing.T) {
+	polygons := boxPolygons(geo.BoundingBox{MinLat: 40, MinLng: 28, MaxLat: 41.5, MaxLng: 29})
+	assert.Equal(t, []string{"POLYGON((28 40, 29 40, 29 41.5, 28 41.5, 28 40))"}, polygons)
+}
+
+func TestBoxPolygons_Antimeridian(t *testing.T) {
+	polygons := boxPolygons(geo.BoundingBox{MinLat: -20, MinLng: 170, MaxLat: -10, MaxLng: -170})
+	assert.Equal(t, []string{
+		"POLYGON((170 -20, 180 -20, 180 -10, 170 -10, 170 -20))",
+		"POLYGON((-180 -20, -170 -20, -170 -10, -180 -10, -180 -20))",
+	}, polygons)
+}
+
+func TestBoxPolygons_SplitsWideBoxes(t *testing.T) {
+	polygons := boxPolygons(geo.BoundingBox{MinLat: 0, MinLng: -180, MaxLat: 10, MaxLng: 180})
+	assert.Len(t, polygons, 4)
+	assert.Equal(t, "POLYGON((90 0, 180 0, 180 10, 90 10, 90 0))", polygons[3])
+}
+
+// mysqlQueryCenters cover an ordinary region, both sides of the antimeridian
+// and the polar fallback without a spatial prefilter.
+var mysqlQueryCenters = []struct {
+	lat, lng, radiusKm float64
+}{
+	{41, 29, 800},
+	{0, 179.5, 600},
+	{-10, -179.8, 1500},
+	{88.5, 10, 400},
+}
+
+// openMySQLWithReference returns the MySQL repository and the generic
+// implementation over SQLite, both holding the same scattered locations.
+func openMySQLWithReference(t *testing.T) (LocationRepository, LocationRepository) {
+	t.Helper()
+	ctx := context.Background()
+	mysqlRepo := NewLocationRepository(openServer(t, "TEST_MYSQL_DSN", mysql.Open))
+	require.IsType(t, &mysqlLocationRepository{}, mysqlRepo)
+	reference := &locationRepository{db: openSQLite(t)}
+
+	// jittered points avoid distance ties that rounding could order differently
+	rng := rand.New(rand.NewSource(1))
+	var locations []model.Location
+	for lat := -80.0; lat <= 80; lat += 10 {
+		for lng := -180.0; lng < 180; lng += 15 {
+			locations = append(locations, model.Location{
+				Name:      fmt.Sprintf("p%d", len(locations)),
+				Latitude:  lat + rng.Float64()*5,
+				Longitude: lng + rng.Float64()*5,
+				Color:     "#ff0000",
+			})
+		}
+	}
+	locations = append(locations,
+		model.Location{Name: "east of 180", Latitude: 0.1, Longitude: 179.9, Color: "#00ff00"},
+		model.Location{Name: "west of 180", Latitude: -0.1, Longitude: -179.9, Color: "#00ff00"},
+		model.Location{Name: "near the pole", Latitude: 89.6, Longitude: -120, Color: "#00ff00"},
+	)
+
+	require.NoError(t, mysqlRepo.CreateBatch(ctx, slices.Clone(locations)))
+	require.NoError(t, reference.CreateBatch(ctx, slices.Clone(locations)))
+	return mysqlRepo, reference
+}
+
+func assertSameNearby(t *testing.T, want, got []model.NearbyLocation) {
+	t.Helper()
+	require.Equal(t, nearbyNames(want), nearbyNames(got))
+	for i := range want {
+		assert.InDelta(t, want[i].Distance, got[i].Distance, 1e-6, want[i].Name)
+	}
+}
+
+func TestMySQLRepository_DistancesMatchGenericImplementation(t *testing.T) {
+	ctx := context.Background()
+	mysqlRepo, reference := openMySQLWithReference(t)
+
+	for _, c := range mysqlQueryCenters {
+		want, err := reference.FindWithinRadius(ctx, c.lat, c.lng, c.radiusKm, 1000, 0)
+		require.NoError(t, err)
+		require.NotEmpty(t, want, "radius %v around (%v, %v)", c.radiusKm, c.lat, c.lng)
+		got, err := mysqlRepo.FindWithinRadius(ctx, c.lat, c.lng, c.radiusKm, 1000, 0)
+		require.NoError(t, err)
+		assertSameNearby(t, want, got)
+
+		page, err := mysqlRepo.FindWithinRadius(ctx, c.lat, c.lng, c.radiusKm, 2, 1)
+		require.NoError(t, err)
+		assertSameNearby(t, geo.Paginate(want, 2, 1), page)
+
+		for _, color := range []string{"", "#00FF00"} {
+			want, err := reference.FindNearest(ctx, c.lat, c.lng, 5, color)
+			require.NoError(t, err)
+			got, err := mysqlRepo.FindNearest(ctx, c.lat, c.lng, 5, color)
+			require.NoError(t, err)
+			assertSameNearby(t, want, got)
+		}
+	}
+}
+
+func TestMySQLRepository_BoxesMatchGenericImplementation(t *testing.T) {
+	ctx := context.Background()
+	mysqlRepo, reference := openMySQLWithReference(t)
+
+	for _, box := range []geo.BoundingBox{
+		{MinLat: 35, MinLng: 25, MaxLat: 45, MaxLng: 45},
+		{MinLat: -20, MinLng: 170, MaxLat: 20, MaxLng: -170},
+		{MinLat: -60, MinLng: -180, MaxLat: 60, MaxLng: 180},
+		{MinLat: 80, MinLng: -180, MaxLat: 90, MaxLng: 180},
+	} {
+		want, err := reference.GetPaginatedLocationsInBox(ctx, box, 1000, 0)
+		require.NoError(t, err)
+		require.NotEmpty(t, want, "%+v", box)
+		got, err := mysqlRepo.GetPaginatedLocationsInBox(ctx, box, 1000, 0)
+		require.NoError(t, err)
+		assert.Equal(t, names(want), names(got), "%+v", box)
+	}
+}
+
+func TestMySQLRepository_PrefilterUsesSpatialIndex(t *testing.T) {
+	db := openServer(t, "TEST_MYSQL_DSN", mysql.Open)
+	seed(t, context.Background(), NewLocationRepository(db))
+
+	var plan []struct {
+		PossibleKeys *string `gorm:"column:possible_keys"`
+	}
+	box := geo.BoundingBoxAround(41, 29, 50)
+	query := withinSpatialBox(db.Model(&model.Location{}).Select("id"), box)
+	stmt := query.Session(&gorm.Session{DryRun: true}).Find(&[]model.Location{}).Statement
+	require.NoError(t, db.Raw("EXPLAIN "+stmt.SQL.String(), stmt.Vars...).Scan(&plan).Error)
+	require.NotEmpty(t, plan)
+	require.NotNil(t, plan[0].PossibleKeys)
+	assert.Contains(t, *plan[0].PossibleKeys, "idx_locations_position")
+}
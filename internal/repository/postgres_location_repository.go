@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/yusufbulac/location-routing-service/internal/geo"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// postgisSphereRadiusMeters is the mean Earth radius PostGIS uses for
+	// geography calculations on the sphere. Distances are rescaled to
+	// geo.EarthRadiusKm so that results match the other implementations.
+	postgisSphereRadiusMeters = 6371008.7714150598
+	// postgisMetersPerKm converts kilometres on the geo.EarthRadiusKm sphere to
+	// metres on the PostGIS sphere.
+	postgisMetersPerKm = postgisSphereRadiusMeters / geo.EarthRadiusKm
+
+	geographySQL         = "ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography"
+	geographyDistanceSQL = "ST_Distance(position, " + geographySQL + ", false) / ?"
+)
+
+// postgresLocationRepository answers spatial queries with the PostGIS
+// geography `position` column and its GiST index: ST_DWithin filters radius
+// searches and the <-> operator orders k-nearest searches through the index.
+// Distances are computed on the sphere, like the generic implementation.
+type postgresLocationRepository struct {
+	*locationRepository
+}
+
+func (r *postgresLocationRepository) FindWithinRadius(ctx context.Context, lat, lng, radiusKm float64, limit, offset int) ([]model.NearbyLocation, error) {
+	nearby := []model.NearbyLocation{}
+	err := r.selectDistance(r.db.WithContext(ctx), lat, lng).
+		Where("ST_DWithin(position, "+geographySQL+", ?, false)", lng, lat, radiusKm*postgisMetersPerKm).
+		Order("distance, id").
+		Limit(limit).
+		Offset(offset).
+		Find(&nearby).Error
+	if err != nil {
+		return nil, err
+	}
+	return nearby, nil
+}
+
+func (r *postgresLocationRepository) FindNearest(ctx context.Context, lat, lng float64, k int, color string) ([]model.NearbyLocation, error) {
+	query := withColor(r.selectDistance(r.db.WithContext(ctx), lat, lng), color)
+
+	nearby := []model.NearbyLocation{}
+	err := query.
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "position <-> " + geographySQL + ", id",
+			Vars:               []interface{}{lng, lat},
+			WithoutParentheses: true,
+		}}).
+		Limit(k).
+		Find(&nearby).Error
+	if err != nil {
+		return nil, err
+	}
+	return nearby, nil
+}
+
+// selectDistance selects every location column plus its distance in
+// kilometres from the given point.
+func (r *postgresLocationRepository) selectDistance(db *gorm.DB, lat, lng float64) *gorm.DB {
+	return db.Model(&model.Location{}).
+		Select("locations.*, "+geographyDistanceSQL+" AS distance", lng, lat, postgisMetersPerKm)
+}
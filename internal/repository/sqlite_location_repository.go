@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/yusufbulac/location-routing-service/internal/geo"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+)
+
+// sqliteDistanceSQL is the haversine distance in kilometres from (?, ?) to a
+// row, taking the Earth radius, latitude, latitude and longitude as arguments.
+// It relies on SQLite's built-in math functions.
+const sqliteDistanceSQL = "? * 2 * asin(min(1, sqrt(" +
+	"power(sin(radians(latitude - ?) / 2), 2) + " +
+	"cos(radians(?)) * cos(radians(latitude)) * power(sin(radians(longitude - ?) / 2), 2))))"
+
+// sqliteLocationRepository lets SQLite compute distances so radius and
+// nearest queries sort and page in SQL instead of loading every candidate of
+// the bounding box.
+type sqliteLocationRepository struct {
+	*locationRepository
+}
+
+// sqliteDistance prefilters on the latitude/longitude index.
+var sqliteDistance = sqlDistance{
+	expr: func(lat, lng float64) (string, []interface{}) {
+		return sqliteDistanceSQL, []interface{}{geo.EarthRadiusKm, lat, lat, lng}
+	},
+	prefilter: withinBoundingBox,
+}
+
+func (r *sqliteLocationRepository) FindWithinRadius(ctx context.Context, lat, lng, radiusKm float64, limit, offset int) ([]model.NearbyLocation, error) {
+	return sqliteDistance.withinRadius(r.db.WithContext(ctx), lat, lng, radiusKm, limit, offset)
+}
+
+func (r *sqliteLocationRepository) FindNearest(ctx context.Context, lat, lng float64, k int, color string) ([]model.NearbyLocation, error) {
+	return sqliteDistance.nearest(r.db.WithContext(ctx), lat, lng, k, color)
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yusufbulac/location-routing-service/internal/geo"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+)
+
+func TestSQLiteRepository_DistancesMatchHaversine(t *testing.T) {
+	repo := NewLocationRepository(openSQLite(t))
+	require.IsType(t, &sqliteLocationRepository{}, repo)
+
+	ctx := context.Background()
+	locations := []model.Location{
+		{Name: "Istanbul", Latitude: 41.0082, Longitude: 28.9784, Color: "#ff0000"},
+		{Name: "Ankara", Latitude: 39.9334, Longitude: 32.8597, Color: "#00ff00"},
+		{Name: "Fiji", Latitude: -17.7134, Longitude: 178.065, Color: "#0000ff"},
+	}
+	require.NoError(t, repo.CreateBatch(ctx, locations))
+
+	nearby, err := repo.FindWithinRadius(ctx, 40.5, 30.5, 1000, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, nearby, 2)
+	for _, n := range nearby {
+		assert.InDelta(t, geo.Haversine(40.5, 30.5, n.Latitude, n.Longitude), n.Distance, 1e-6, n.Name)
+	}
+
+	nearest, err := repo.FindNearest(ctx, -17, -179.5, 1, "")
+	require.NoError(t, err)
+	require.Len(t, nearest, 1)
+	assert.Equal(t, "Fiji", nearest[0].Name, "distances wrap around the antimeridian")
+}
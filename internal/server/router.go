@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"github.com/yusufbulac/location-routing-service/internal/cache"
+	"github.com/yusufbulac/location-routing-service/internal/config"
+	"github.com/yusufbulac/location-routing-service/internal/handler"
+	"github.com/yusufbulac/location-routing-service/internal/logger"
+	"github.com/yusufbulac/location-routing-service/internal/middleware"
+	"github.com/yusufbulac/location-routing-service/internal/repository"
+	"github.com/yusufbulac/location-routing-service/internal/service"
+	"github.com/yusufbulac/location-routing-service/internal/spatial"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// exportCSVPath is the route of the CSV export.
+const exportCSVPath = "/api/v1/locations/export.csv"
+
+// NewLocationService wires the location repository and service over db and
+// routeCache, the same way for the HTTP server and the command-line tools.
+// ctx bounds building the spatial index.
+func NewLocationService(ctx context.Context, cfg *config.Config, db *gorm.DB, routeCache cache.Cache) (service.LocationService, error) {
+	locationRepo := repository.NewLocationRepository(db)
+	if cfg.SpatialIndexEnabled {
+		index := spatial.NewIndex(spatial.DefaultCellSize)
+		indexedRepo, err := repository.NewIndexedLocationRepository(ctx, locationRepo, index)
+		if err != nil {
+			return nil, fmt.Errorf("build spatial index: %w", err)
+		}
+		locationRepo = indexedRepo
+		logger.Info("Spatial index built", zap.Int("locations", index.Len()))
+	}
+	matrix := service.MatrixSettings{
+		MaxPoints:   cfg.Matrix.MaxPoints,
+		MaxElements: cfg.Matrix.MaxElements,
+		SpeedKmh:    cfg.Matrix.SpeedKmh,
+	}
+	return service.NewLocationService(locationRepo, service.WithCache(routeCache), service.WithMatrixSettings(matrix)), nil
+}
+
+// NewRouter wires the repositories, services and handlers over db and
+// routeCache and returns the HTTP router. ctx bounds the startup work, such as
+// building the spatial index.
+func NewRouter(ctx context.Context, cfg *config.Config, db *gorm.DB, routeCache cache.Cache) (*gin.Engine, error) {
+	r := gin.Default()
+	r.Use(gin.Recovery())
+
+	// middlewares
+	r.Use(middleware.ZapLogger())
+	r.Use(middleware.RateLimitMiddleware(cfg.RateLimit.Requests, cfg.RateLimit.Period))
+	// streaming exports outlive the request deadline and get one of their own
+	r.Use(middleware.RequestTimeout(cfg.Server.RequestTimeout, exportCSVPath))
+
+	// dependencies
+	locationService, err := NewLocationService(ctx, cfg, db, routeCache)
+	if err != nil {
+		return nil, err
+	}
+	locationHandler := handler.NewLocationHandler(locationService)
+	healthHandler := handler.NewHealthHandler(routeCache)
+	adminHandler := handler.NewAdminHandler(locationService, cfg.SoftDeleteRetention)
+
+	// Routes
+	r.GET("/health", healthHandler.Health)
+
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	api := r.Group("/api/v1")
+	{
+		api.POST("/locations", locationHandler.CreateLocation)
+		api.POST("/locations/batch", locationHandler.CreateLocationsBatch)
+		api.POST("/locations/import", middleware.MaxBodySize(cfg.ImportMaxBytes), locationHandler.ImportLocations)
+		api.GET("/locations", locationHandler.GetAllLocations)
+		api.GET("/locations/export.csv", middleware.RequestTimeout(cfg.Server.ExportTimeout), locationHandler.ExportLocationsCSV)
+		api.GET("/locations/nearby", locationHandler.GetNearbyLocations)
+		api.GET("/locations/nearest", locationHandler.GetNearestLocations)
+		api.GET("/locations/:id", locationHandler.GetLocationByID)
+		api.PUT("/locations/:id", locationHandler.UpdateLocation)
+		api.DELETE("/locations/:id", locationHandler.DeleteLocation)
+		api.GET("/route", locationHandler.GetRoute)
+		api.POST("/routes", locationHandler.BuildRoute)
+		api.POST("/matrix", locationHandler.GetDistanceMatrix)
+
+		// admin endpoints stay unregistered unless a token protects them
+		if cfg.AdminToken != "" {
+			admin := api.Group("/admin", middleware.AdminAuth(cfg.AdminToken))
+			admin.POST("/locations/:id/restore", adminHandler.RestoreLocation)
+			admin.DELETE("/locations/deleted", adminHandler.PurgeDeletedLocations)
+		} else {
+			logger.Warn("Admin endpoints disabled; set ADMIN_TOKEN to enable them")
+		}
+	}
+
+	return r, nil
+}
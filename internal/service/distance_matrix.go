@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/yusufbulac/location-routing-service/internal/geo"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+)
+
+// ErrMatrixTooLarge is returned for distance matrices over the configured limits.
+var ErrMatrixTooLarge = errors.New("distance matrix too large")
+
+// MatrixSettings limits distance matrices and sets how durations are estimated.
+type MatrixSettings struct {
+	// MaxPoints caps the number of origins and of destinations.
+	MaxPoints int
+	// MaxElements caps origins × destinations.
+	MaxElements int
+	// SpeedKmh is the average speed durations are estimated with when the
+	// caller does not give one.
+	SpeedKmh float64
+}
+
+// DefaultMatrixSettings apply unless WithMatrixSettings is given.
+var DefaultMatrixSettings = MatrixSettings{MaxPoints: 1000, MaxElements: 250000, SpeedKmh: 50}
+
+// WithMatrixSettings sets the distance matrix limits and default speed.
+func WithMatrixSettings(m MatrixSettings) Option {
+	return func(s *locationService) {
+		s.matrix = m
+	}
+}
+
+// DistanceMatrix returns the great-circle distances and estimated durations
+// from every origin to every destination. Points with a LocationID take the
+// coordinates of that location; durations assume speedKmh, or the configured
+// default speed when it is zero. Rows are computed in parallel.
+func (s *locationService) DistanceMatrix(ctx context.Context, origins, destinations []model.MatrixPoint, speedKmh float64) (*model.DistanceMatrix, error) {
+	if err := s.checkMatrixSize(len(origins), len(destinations)); err != nil {
+		return nil, err
+	}
+	if speedKmh <= 0 {
+		speedKmh = s.matrix.SpeedKmh
+	}
+
+	origins, destinations = slices.Clone(origins), slices.Clone(destinations)
+	if err := s.resolveMatrixPoints(ctx, origins, destinations); err != nil {
+		return nil, err
+	}
+
+	distances, durations, err := fillMatrix(ctx, origins, destinations, speedKmh)
+	if err != nil {
+		return nil, err
+	}
+	return &model.DistanceMatrix{
+		Origins:      origins,
+		Destinations: destinations,
+		Distances:    distances,
+		Durations:    durations,
+		SpeedKmh:     speedKmh,
+	}, nil
+}
+
+func (s *locationService) checkMatrixSize(origins, destinations int) error {
+	switch {
+	case origins > s.matrix.MaxPoints:
+		return fmt.Errorf("%w: %d origins exceed the limit of %d", ErrMatrixTooLarge, origins, s.matrix.MaxPoints)
+	case destinations > s.matrix.MaxPoints:
+		return fmt.Errorf("%w: %d destinations exceed the limit of %d", ErrMatrixTooLarge, destinations, s.matrix.MaxPoints)
+	case origins*destinations > s.matrix.MaxElements:
+		return fmt.Errorf("%w: %d×%d elements exceed the limit of %d", ErrMatrixTooLarge, origins, destinations, s.matrix.MaxElements)
+	}
+	return nil
+}
+
+// resolveMatrixPoints fills in the coordinates of points that name a location,
+// loading every referenced location with a single query.
+func (s *locationService) resolveMatrixPoints(ctx context.Context, points ...[]model.MatrixPoint) error {
+	var ids []uint
+	for _, side := range points {
+		for _, p := range side {
+			if p.LocationID != 0 {
+				ids = append(ids, p.LocationID)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	locations, err := s.findLocations(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uint]model.Location, len(locations))
+	for _, loc := range locations {
+		byID[loc.ID] = loc
+	}
+	for _, side := range points {
+		for i, p := range side {
+			if loc, ok := byID[p.LocationID]; ok {
+				side[i].Latitude, side[i].Longitude = loc.Latitude, loc.Longitude
+			}
+		}
+	}
+	return nil
+}
+
+// fillMatrix computes the matrix rows on up to GOMAXPROCS workers. Each worker
+// takes the next unclaimed row, so rows never share a writer.
+func fillMatrix(ctx context.Context, origins, destinations []model.MatrixPoint, speedKmh float64) ([][]float64, [][]float64, error) {
+	n, m := len(origins), len(destinations)
+	distanceCells, durationCells := make([]float64, n*m), make([]float64, n*m)
+	distances, durations := make([][]float64, n), make([][]float64, n)
+	for i := range distances {
+		distances[i] = distanceCells[i*m : (i+1)*m : (i+1)*m]
+		durations[i] = durationCells[i*m : (i+1)*m : (i+1)*m]
+	}
+
+	secondsPerKm := 3600 / speedKmh
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := min(runtime.GOMAXPROCS(0), n); w > 0; w-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if i >= n || ctx.Err() != nil {
+					return
+				}
+				o := origins[i]
+				for j, d := range destinations {
+					km := geo.Haversine(o.Latitude, o.Longitude, d.Latitude, d.Longitude)
+					distances[i][j] = km
+					durations[i][j] = km * secondsPerKm
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	return distances, durations, nil
+}
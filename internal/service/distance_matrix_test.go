@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	testifymock "github.com/stretchr/testify/mock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yusufbulac/location-routing-service/internal/geo"
+	"github.com/yusufbulac/location-routing-service/internal/mock"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+	"gorm.io/gorm"
+)
+
+func TestDistanceMatrix(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+	mockRepo.On("FindByIDs", testifymock.Anything, []uint{1, 2, 4}).Return([]model.Location{depotLocations[0], depotLocations[1], depotLocations[3]}, nil).Once()
+
+	origins := []model.MatrixPoint{{LocationID: 1}, {Latitude: 0, Longitude: 0.1}}
+	destinations := []model.MatrixPoint{{LocationID: 1}, {LocationID: 4}, {LocationID: 2}}
+	result, err := service.DistanceMatrix(context.Background(), origins, destinations, 100)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.MatrixPoint{LocationID: 1, Latitude: 0, Longitude: 0.3}, result.Destinations[0])
+	assert.Zero(t, origins[0].Longitude, "the caller's points must not be modified")
+	if assert.Len(t, result.Distances, 2) {
+		assert.Zero(t, result.Distances[0][0])
+		assert.InDelta(t, geo.Haversine(0, 0.3, 0, 0), result.Distances[0][2], 1e-9)
+		assert.InDelta(t, geo.Haversine(0, 0.1, 0, 0.2), result.Distances[1][1], 1e-9)
+	}
+	assert.InDelta(t, result.Distances[1][0]/100*3600, result.Durations[1][0], 1e-9)
+	assert.Equal(t, float64(100), result.SpeedKmh)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDistanceMatrix_CoordinatesOnly(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo, WithMatrixSettings(MatrixSettings{MaxPoints: 10, MaxElements: 100, SpeedKmh: 60}))
+	points := []model.MatrixPoint{{Latitude: 41.0082, Longitude: 28.9784}, {Latitude: 39.9334, Longitude: 32.8597}}
+
+	result, err := service.DistanceMatrix(context.Background(), points, points, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, float64(60), result.SpeedKmh, "the configured speed applies by default")
+	assert.Equal(t, result.Distances[0][1], result.Distances[1][0])
+	assert.InDelta(t, 350, result.Distances[0][1], 10)
+	assert.InDelta(t, result.Distances[0][1]*60, result.Durations[0][1], 1e-9)
+	mockRepo.AssertNotCalled(t, "FindByIDs", testifymock.Anything, testifymock.Anything)
+}
+
+func TestDistanceMatrix_MissingLocations(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+	mockRepo.On("FindByIDs", testifymock.Anything, []uint{1, 7}).Return([]model.Location{depotLocations[0]}, nil)
+
+	_, err := service.DistanceMatrix(context.Background(), []model.MatrixPoint{{LocationID: 7}}, []model.MatrixPoint{{LocationID: 1}}, 0)
+
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.ErrorContains(t, err, "locations [7]")
+}
+
+func TestDistanceMatrix_Limits(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo, WithMatrixSettings(MatrixSettings{MaxPoints: 3, MaxElements: 6, SpeedKmh: 50}))
+	points := func(n int) []model.MatrixPoint {
+		return make([]model.MatrixPoint, n)
+	}
+
+	_, err := service.DistanceMatrix(context.Background(), points(4), points(1), 0)
+	assert.ErrorIs(t, err, ErrMatrixTooLarge)
+	assert.ErrorContains(t, err, "4 origins exceed the limit of 3")
+
+	_, err = service.DistanceMatrix(context.Background(), points(3), points(3), 0)
+	assert.ErrorIs(t, err, ErrMatrixTooLarge)
+	assert.ErrorContains(t, err, "3×3 elements exceed the limit of 6")
+
+	result, err := service.DistanceMatrix(context.Background(), points(3), points(2), 0)
+	assert.NoError(t, err)
+	assert.Len(t, result.Distances, 3)
+	mockRepo.AssertNotCalled(t, "FindByIDs", testifymock.Anything, testifymock.Anything)
+}
+
+func TestDistanceMatrix_Cancelled(t *testing.T) {
+	service := NewLocationService(new(mock.MockLocationRepository))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := service.DistanceMatrix(ctx, make([]model.MatrixPoint, 50), make([]model.MatrixPoint, 50), 0)
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
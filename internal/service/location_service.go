@@ -1,99 +1,464 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/yusufbulac/location-routing-service/internal/cache"
+	"github.com/yusufbulac/location-routing-service/internal/geo"
 	"github.com/yusufbulac/location-routing-service/internal/logger"
 	"github.com/yusufbulac/location-routing-service/internal/model"
 	"github.com/yusufbulac/location-routing-service/internal/repository"
 	"go.uber.org/zap"
-	"math"
-	"sort"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
 type LocationService interface {
-	CreateLocation(location *model.Location) error
-	GetAllLocations() ([]model.Location, error)
-	GetLocationByID(id uint) (*model.Location, error)
-	UpdateLocation(location *model.Location) error
-	GetRouteFrom(lat, lng float64) ([]model.Location, error)
-	GetPaginatedLocations(limit, offset int) ([]model.Location, error)
+	CreateLocation(ctx context.Context, location *model.Location) error
+	CreateLocations(ctx context.Context, locations []model.Location) error
+	GetAllLocations(ctx context.Context) ([]model.Location, error)
+	StreamLocations(ctx context.Context, fn func([]model.Location) error) error
+	GetLocationByID(ctx context.Context, id uint) (*model.Location, error)
+	UpdateLocation(ctx context.Context, location *model.Location) error
+	GetRouteFrom(ctx context.Context, lat, lng float64) (*model.Route, error)
+	GetRoute(ctx context.Context, opts RouteOptions) (*model.Route, error)
+	GetPaginatedLocations(ctx context.Context, limit, offset int) ([]model.Location, error)
+	GetPaginatedLocationsInBox(ctx context.Context, box geo.BoundingBox, limit, offset int) ([]model.Location, error)
+	DeleteLocation(ctx context.Context, id uint) error
+	RestoreLocation(ctx context.Context, id uint) (*model.Location, error)
+	PurgeDeletedLocations(ctx context.Context, olderThan time.Duration) (int64, error)
+	GetNearbyLocations(ctx context.Context, lat, lng, radiusKm float64, limit, offset int) ([]model.NearbyLocation, error)
+	GetNearestLocations(ctx context.Context, lat, lng float64, k int, color string) ([]model.NearbyLocation, error)
+	DistanceMatrix(ctx context.Context, origins, destinations []model.MatrixPoint, speedKmh float64) (*model.DistanceMatrix, error)
 }
 
+const (
+	// routeCacheTTL is how long a computed route is served as fresh.
+	routeCacheTTL = 5 * time.Minute
+	// routeStaleTTL is how long an expired route may still be served while
+	// it is being recomputed.
+	routeStaleTTL = time.Minute
+	// routeComputeTimeout bounds a route computation shared between callers.
+	routeComputeTimeout = 30 * time.Second
+	// streamBatchSize is the number of locations StreamLocations reads at a time.
+	streamBatchSize = 1000
+)
+
 type locationService struct {
-	repo repository.LocationRepository
+	repo    repository.LocationRepository
+	cache   cache.Cache
+	flights singleflight.Group
+	matrix  MatrixSettings
+	now     func() time.Time
+	// pendingBump records a failed invalidation, retried before the next
+	// route cache read.
+	pendingBump atomic.Bool
 }
 
-func NewLocationService(repo repository.LocationRepository) LocationService {
-	return &locationService{repo: repo}
+// Option configures optional dependencies of the location service.
+type Option func(*locationService)
+
+// WithCache enables route caching in c.
+func WithCache(c cache.Cache) Option {
+	return func(s *locationService) {
+		s.cache = c
+	}
 }
 
-func (s *locationService) CreateLocation(location *model.Location) error {
-	return s.repo.Create(location)
+func NewLocationService(repo repository.LocationRepository, opts ...Option) LocationService {
+	s := &locationService{repo: repo, matrix: DefaultMatrixSettings, now: time.Now}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func (s *locationService) GetAllLocations() ([]model.Location, error) {
-	return s.repo.FindAll()
+func (s *locationService) CreateLocation(ctx context.Context, location *model.Location) error {
+	if err := s.repo.Create(ctx, location); err != nil {
+		return err
+	}
+	s.invalidateRoutes(ctx)
+	return nil
 }
 
-func (s *locationService) GetLocationByID(id uint) (*model.Location, error) {
-	return s.repo.FindByID(id)
+// CreateLocations stores locations in a single transaction, setting their IDs,
+// and invalidates cached routes once for the whole batch.
+func (s *locationService) CreateLocations(ctx context.Context, locations []model.Location) error {
+	if len(locations) == 0 {
+		return nil
+	}
+	if err := s.repo.CreateBatch(ctx, locations); err != nil {
+		logger.Error("CreateLocations failed", zap.Error(err), zap.Int("count", len(locations)))
+		return err
+	}
+	s.invalidateRoutes(ctx)
+	return nil
 }
 
-func (s *locationService) UpdateLocation(location *model.Location) error {
-	err := s.repo.Update(location)
+func (s *locationService) GetAllLocations(ctx context.Context) ([]model.Location, error) {
+	return s.repo.FindAll(ctx)
+}
+
+// StreamLocations calls fn with every live location in ID order, in batches,
+// without loading the whole table into memory.
+func (s *locationService) StreamLocations(ctx context.Context, fn func([]model.Location) error) error {
+	return s.repo.FindInBatches(ctx, streamBatchSize, fn)
+}
+
+func (s *locationService) GetLocationByID(ctx context.Context, id uint) (*model.Location, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+func (s *locationService) UpdateLocation(ctx context.Context, location *model.Location) error {
+	err := s.repo.Update(ctx, location)
 	if err != nil {
 		logger.Error("UpdateLocation failed", zap.Error(err), zap.Uint("id", location.ID))
 		return err
 	}
+	s.invalidateRoutes(ctx)
 	return nil
 }
 
-func (s *locationService) GetPaginatedLocations(limit, offset int) ([]model.Location, error) {
-	return s.repo.GetPaginatedLocations(limit, offset)
+func (s *locationService) GetPaginatedLocations(ctx context.Context, limit, offset int) ([]model.Location, error) {
+	return s.repo.GetPaginatedLocations(ctx, limit, offset)
 }
 
-func (s *locationService) GetRouteFrom(lat, lng float64) ([]model.Location, error) {
-	key := fmt.Sprintf("route:%.4f:%.4f", lat, lng)
+func (s *locationService) GetPaginatedLocationsInBox(ctx context.Context, box geo.BoundingBox, limit, offset int) ([]model.Location, error) {
+	return s.repo.GetPaginatedLocationsInBox(ctx, box, limit, offset)
+}
 
-	// check redis
-	if cached, err := cache.Redis.Get(cache.Ctx, key).Result(); err == nil {
-		var locations []model.Location
-		if err := json.Unmarshal([]byte(cached), &locations); err == nil {
-			return locations, nil
-		}
+func (s *locationService) DeleteLocation(ctx context.Context, id uint) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		logger.Error("DeleteLocation failed", zap.Error(err), zap.Uint("id", id))
+		return err
+	}
+	s.invalidateRoutes(ctx)
+	return nil
+}
+
+func (s *locationService) RestoreLocation(ctx context.Context, id uint) (*model.Location, error) {
+	if err := s.repo.Restore(ctx, id); err != nil {
+		logger.Error("RestoreLocation failed", zap.Error(err), zap.Uint("id", id))
+		return nil, err
 	}
+	s.invalidateRoutes(ctx)
+	return s.repo.FindByID(ctx, id)
+}
 
-	locations, err := s.repo.FindAll()
+// PurgeDeletedLocations permanently removes locations that were soft-deleted
+// more than olderThan ago and returns how many rows were removed.
+func (s *locationService) PurgeDeletedLocations(ctx context.Context, olderThan time.Duration) (int64, error) {
+	purged, err := s.repo.PurgeDeleted(ctx, time.Now().Add(-olderThan))
 	if err != nil {
+		logger.Error("PurgeDeletedLocations failed", zap.Error(err))
+		return 0, err
+	}
+	return purged, nil
+}
+
+func (s *locationService) GetNearbyLocations(ctx context.Context, lat, lng, radiusKm float64, limit, offset int) ([]model.NearbyLocation, error) {
+	return s.repo.FindWithinRadius(ctx, lat, lng, radiusKm, limit, offset)
+}
+
+// GetNearestLocations returns the k locations closest to the given point. An
+// empty color matches every marker color.
+func (s *locationService) GetNearestLocations(ctx context.Context, lat, lng float64, k int, color string) ([]model.NearbyLocation, error) {
+	return s.repo.FindNearest(ctx, lat, lng, k, color)
+}
+
+// GetRouteFrom builds a visiting order over all locations starting at the given
+// reference point. See GetRoute.
+func (s *locationService) GetRouteFrom(ctx context.Context, lat, lng float64) (*model.Route, error) {
+	return s.GetRoute(ctx, RouteOptions{Lat: lat, Lng: lng})
+}
+
+// GetRoute builds a visiting order over all locations from the start chosen
+// by opts, finishing at the end location or back at the start if requested.
+// Each next stop is chosen relative to the previous one and the resulting path
+// is refined with 2-opt and Or-opt moves that keep the anchors in place. A
+// start or end location that does not exist is reported as
+// gorm.ErrRecordNotFound.
+//
+// Concurrent requests for the same cache key share a single computation. A
+// cached route past its freshness window is still served for routeStaleTTL
+// while one background refresh recomputes it. A caller whose ctx ends while
+// waiting for a shared computation returns ctx.Err() without cancelling it.
+func (s *locationService) GetRoute(ctx context.Context, opts RouteOptions) (*model.Route, error) {
+	if err := opts.Validate(); err != nil {
 		return nil, err
 	}
+	opts = opts.normalize()
+
+	key, cacheable := s.routeCacheKey(ctx, opts)
+	if !cacheable {
+		return s.computeRoute(ctx, opts, "")
+	}
+
+	// check cache
+	if cached, err := s.cache.Get(ctx, key); err == nil {
+		var entry cachedRoute
+		if err := json.Unmarshal(cached, &entry); err == nil && entry.Route != nil {
+			if s.now().After(entry.FreshUntil) {
+				s.refreshRoute(ctx, opts, key)
+			}
+			return entry.Route, nil
+		}
+	}
+
+	flight := s.flights.DoChan(key, func() (interface{}, error) {
+		return s.computeShared(ctx, opts, key)
+	})
+	select {
+	case result := <-flight:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.(*model.Route), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
 
-	sort.Slice(locations, func(i, j int) bool {
-		distA := haversine(lat, lng, locations[i].Latitude, locations[i].Longitude)
-		distB := haversine(lat, lng, locations[j].Latitude, locations[j].Longitude)
-		return distA < distB
+// cachedRoute is the cache representation of a route.
+type cachedRoute struct {
+	Route      *model.Route `json:"route"`
+	FreshUntil time.Time    `json:"fresh_until"`
+}
+
+// refreshRoute recomputes a stale route in the background. DoChan joins an
+// in-flight computation for the key, so at most one refresh runs at a time.
+func (s *locationService) refreshRoute(ctx context.Context, opts RouteOptions, key string) {
+	s.flights.DoChan(key, func() (interface{}, error) {
+		route, err := s.computeShared(ctx, opts, key)
+		if err != nil {
+			logger.Warn("Background route refresh failed", zap.Error(err), zap.String("key", key))
+		}
+		return route, err
 	})
+}
+
+// computeShared runs computeRoute for a computation other callers may join.
+// It keeps the values of ctx but not its cancellation, so one caller going
+// away does not fail the others; routeComputeTimeout bounds it instead.
+func (s *locationService) computeShared(ctx context.Context, opts RouteOptions, key string) (*model.Route, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), routeComputeTimeout)
+	defer cancel()
+	return s.computeRoute(ctx, opts, key)
+}
+
+// computeRoute loads all locations, builds the route and, when key is not
+// empty, caches it.
+func (s *locationService) computeRoute(ctx context.Context, opts RouteOptions, key string) (*model.Route, error) {
+	locations, err := s.routeLocations(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := routePlan{origin: geoPoint{Lat: opts.Lat, Lng: opts.Lng}, start: -1, end: -1, closed: opts.ReturnToStart}
+	if opts.StartID != 0 {
+		if locations, plan.start, err = s.routeAnchor(ctx, locations, opts, opts.StartID, "start"); err != nil {
+			return nil, err
+		}
+	}
+	if opts.EndID != 0 {
+		if locations, plan.end, err = s.routeAnchor(ctx, locations, opts, opts.EndID, "end"); err != nil {
+			return nil, err
+		}
+	}
+
+	route := buildRoute(plan, locations)
 
 	// add cache
-	if jsonBytes, err := json.Marshal(locations); err == nil {
-		cache.Redis.Set(cache.Ctx, key, jsonBytes, 5*time.Minute)
+	if key != "" {
+		entry := cachedRoute{Route: route, FreshUntil: s.now().Add(routeCacheTTL)}
+		if jsonBytes, err := json.Marshal(entry); err == nil {
+			if err := s.cache.Set(ctx, key, jsonBytes, routeCacheTTL+routeStaleTTL); err != nil {
+				logger.Warn("Could not cache route", zap.Error(err))
+			}
+		}
+	}
+
+	return route, nil
+}
+
+// routeLocations loads the locations selected by opts. Selected IDs must all
+// exist; missing ones are reported as gorm.ErrRecordNotFound. Colors then
+// narrow the selection.
+func (s *locationService) routeLocations(ctx context.Context, opts RouteOptions) ([]model.Location, error) {
+	if !opts.selective() {
+		return s.repo.FindAll(ctx)
+	}
+	if len(opts.LocationIDs) == 0 {
+		return s.repo.FindByColors(ctx, opts.Colors)
+	}
+
+	locations, err := s.findLocations(ctx, opts.LocationIDs)
+	if err != nil {
+		return nil, err
 	}
 
+	if len(opts.Colors) == 0 {
+		return locations, nil
+	}
+	matching := locations[:0]
+	for _, loc := range locations {
+		if slices.Contains(opts.Colors, strings.ToLower(loc.Color)) {
+			matching = append(matching, loc)
+		}
+	}
+	return matching, nil
+}
+
+// findLocations loads the locations with the given sorted, distinct IDs and
+// reports the missing ones as not found.
+func (s *locationService) findLocations(ctx context.Context, ids []uint) ([]model.Location, error) {
+	locations, err := s.repo.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(locations) < len(ids) {
+		found := make(map[uint]bool, len(locations))
+		for _, loc := range locations {
+			found[loc.ID] = true
+		}
+		var missing []uint
+		for _, id := range ids {
+			if !found[id] {
+				missing = append(missing, id)
+			}
+		}
+		return nil, fmt.Errorf("locations %v: %w", missing, gorm.ErrRecordNotFound)
+	}
 	return locations, nil
 }
 
-func haversine(lat1, lon1, lat2, lon2 float64) float64 {
-	const R = 6371
-	dLat := (lat2 - lat1) * math.Pi / 180
-	dLon := (lon2 - lon1) * math.Pi / 180
+// routeAnchor returns the position of the start or end location with the
+// given ID among locations. An anchor outside a selection is added to it.
+func (s *locationService) routeAnchor(ctx context.Context, locations []model.Location, opts RouteOptions, id uint, anchor string) ([]model.Location, int, error) {
+	for i, loc := range locations {
+		if loc.ID == id {
+			return locations, i, nil
+		}
+	}
+	if !opts.selective() {
+		return nil, -1, fmt.Errorf("%s location %d: %w", anchor, id, gorm.ErrRecordNotFound)
+	}
+	loc, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, -1, fmt.Errorf("%s location %d: %w", anchor, id, err)
+	}
+	return append(locations, *loc), len(locations), nil
+}
+
+// routeCacheKey returns the cache key for a route with the given options. Keys
+// embed the dataset generation of the cache and the data version of the
+// database, so that any location write makes previously cached routes
+// unreachable, including writes by other processes that do not share the
+// cache. Routes are not cached when either is unknown, since a stale entry
+// could not be told apart from a fresh one, and while an earlier invalidation
+// is still pending.
+func (s *locationService) routeCacheKey(ctx context.Context, opts RouteOptions) (string, bool) {
+	if s.cache == nil {
+		return "", false
+	}
+	if s.pendingBump.Load() && !s.bumpGeneration(ctx) {
+		return "", false
+	}
+	gen, err := cache.Generation(ctx, s.cache)
+	if err != nil {
+		logger.Warn("Could not read dataset generation", zap.Error(err))
+		return "", false
+	}
+	version, err := s.repo.DataVersion(ctx)
+	if err != nil {
+		logger.Warn("Could not read data version", zap.Error(err))
+		return "", false
+	}
+	return fmt.Sprintf("route:%d:%d:%s", gen, version, opts.cacheKey()), true
+}
+
+// invalidateRoutes makes every cached route stale after a location write. The
+// write has already happened, so the bump ignores the cancellation of ctx. A
+// failed bump stays pending, and cached routes are bypassed until it succeeds.
+func (s *locationService) invalidateRoutes(ctx context.Context) {
+	if s.cache == nil {
+		return
+	}
+	s.bumpGeneration(context.WithoutCancel(ctx))
+}
+
+// bumpGeneration bumps the dataset generation and reports whether it succeeded.
+func (s *locationService) bumpGeneration(ctx context.Context) bool {
+	s.pendingBump.Store(false)
+	if err := cache.BumpGeneration(ctx, s.cache); err != nil {
+		s.pendingBump.Store(true)
+		logger.Error("Could not invalidate cached routes", zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// routePlan anchors the route built by buildRoute.
+type routePlan struct {
+	// origin is where the route starts when start is -1.
+	origin geoPoint
+	// start and end index the start and end locations, or are -1.
+	start int
+	end   int
+	// closed returns to the start location after the last stop.
+	closed bool
+}
+
+// buildRoute orders locations into a route. Without a start location the route
+// is an open path beginning at plan.origin; its first leg runs from origin to
+// the first stop and is included in the total distance. With one, the start
+// location is Route.Start rather than a stop, and a closed route ends with a
+// stop back at it.
+func buildRoute(plan routePlan, locations []model.Location) *model.Route {
+	points := make([]geoPoint, 0, len(locations)+1)
+	// offset maps point indexes to location indexes
+	offset := 0
+	opts := tourOptions{Start: plan.start, End: plan.end, Closed: plan.closed}
+	if plan.start < 0 {
+		points = append(points, plan.origin)
+		offset = 1
+		opts.Start = 0
+		if plan.end >= 0 {
+			opts.End = plan.end + 1
+		}
+	}
+	for _, loc := range locations {
+		points = append(points, geoPoint{Lat: loc.Latitude, Lng: loc.Longitude})
+	}
 
-	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
-		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*
-			math.Sin(dLon/2)*math.Sin(dLon/2)
+	order := optimizeTour(points, opts)
+	if plan.closed && len(order) > 1 {
+		order = append(order, order[0])
+	}
 
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-	return R * c
+	route := &model.Route{Stops: make([]model.RouteStop, 0, len(order))}
+	if plan.start >= 0 {
+		start := locations[plan.start]
+		route.Start = &start
+	}
+	prev := points[order[0]]
+	for _, idx := range order[1:] {
+		loc := locations[idx-offset]
+		leg := geo.Haversine(prev.Lat, prev.Lng, loc.Latitude, loc.Longitude)
+		route.TotalDistance += leg
+
+		route.Stops = append(route.Stops, model.RouteStop{
+			Location:           loc,
+			LegDistance:        leg,
+			CumulativeDistance: route.TotalDistance,
+			Bearing:            geo.InitialBearing(prev.Lat, prev.Lng, loc.Latitude, loc.Longitude),
+		})
+		prev = points[idx]
+	}
+	return route
 }
@@ -1,12 +1,20 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"github.com/yusufbulac/location-routing-service/internal/mock"
+	"sync"
 	"testing"
+	"time"
+
+	testifymock "github.com/stretchr/testify/mock"
+	"github.com/yusufbulac/location-routing-service/internal/cache"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/yusufbulac/location-routing-service/internal/geo"
 	"github.com/yusufbulac/location-routing-service/internal/model"
+	"gorm.io/gorm"
 )
 
 // --- Unit Tests ---
@@ -15,13 +23,50 @@ func TestCreateLocation(t *testing.T) {
 	service := NewLocationService(mockRepo)
 
 	location := &model.Location{Name: "Test", Latitude: 1.0, Longitude: 1.0, Color: "#FFFFFF"}
-	mockRepo.On("Create", location).Return(nil)
+	mockRepo.On("Create", testifymock.Anything, location).Return(nil)
+
+	err := service.CreateLocation(context.Background(), location)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateLocations(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	routeCache := cache.NewMemoryCache(16)
+	service := NewLocationService(mockRepo, WithCache(routeCache))
+	mockRepo.On("DataVersion", testifymock.Anything).Return(int64(0), nil).Maybe()
+
+	locations := []model.Location{
+		{Name: "A", Latitude: 1, Longitude: 1, Color: "#FFFFFF"},
+		{Name: "B", Latitude: 2, Longitude: 2, Color: "#000000"},
+	}
+	mockRepo.On("CreateBatch", testifymock.Anything, locations).Return(nil).Once()
+
+	assert.NoError(t, service.CreateLocations(context.Background(), locations))
+	assert.NoError(t, service.CreateLocations(context.Background(), nil), "an empty batch is a no-op")
 
-	err := service.CreateLocation(location)
+	gen, err := cache.Generation(context.Background(), routeCache)
 	assert.NoError(t, err)
+	assert.Equal(t, int64(1), gen, "a batch invalidates cached routes once")
 	mockRepo.AssertExpectations(t)
 }
 
+func TestCreateLocations_Error(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	routeCache := cache.NewMemoryCache(16)
+	service := NewLocationService(mockRepo, WithCache(routeCache))
+	mockRepo.On("DataVersion", testifymock.Anything).Return(int64(0), nil).Maybe()
+
+	mockRepo.On("CreateBatch", testifymock.Anything, testifymock.Anything).Return(errors.New("constraint violation"))
+
+	err := service.CreateLocations(context.Background(), []model.Location{{Name: "A"}})
+	assert.EqualError(t, err, "constraint violation")
+
+	gen, err := cache.Generation(context.Background(), routeCache)
+	assert.NoError(t, err)
+	assert.Zero(t, gen, "a failed batch keeps cached routes")
+}
+
 func TestGetAllLocations(t *testing.T) {
 	mockRepo := new(mock.MockLocationRepository)
 	service := NewLocationService(mockRepo)
@@ -30,14 +75,31 @@ func TestGetAllLocations(t *testing.T) {
 		{Name: "Loc1", Latitude: 10, Longitude: 20, Color: "#000000"},
 		{Name: "Loc2", Latitude: 30, Longitude: 40, Color: "#FFFFFF"},
 	}
-	mockRepo.On("FindAll").Return(expected, nil)
+	mockRepo.On("FindAll", testifymock.Anything).Return(expected, nil)
 
-	locations, err := service.GetAllLocations()
+	locations, err := service.GetAllLocations(context.Background())
 	assert.NoError(t, err)
 	assert.Equal(t, expected, locations)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestStreamLocations(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+
+	expected := []model.Location{{ID: 1, Name: "Loc1"}, {ID: 2, Name: "Loc2"}}
+	mockRepo.On("FindInBatches", testifymock.Anything, streamBatchSize).Return(expected, nil)
+
+	var streamed []model.Location
+	err := service.StreamLocations(context.Background(), func(batch []model.Location) error {
+		streamed = append(streamed, batch...)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, streamed)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestGetPaginatedLocations(t *testing.T) {
 	mockRepo := new(mock.MockLocationRepository)
 	service := NewLocationService(mockRepo)
@@ -46,9 +108,25 @@ func TestGetPaginatedLocations(t *testing.T) {
 		{ID: 1, Name: "Pag1", Latitude: 10, Longitude: 10, Color: "#111111"},
 		{ID: 2, Name: "Pag2", Latitude: 20, Longitude: 20, Color: "#222222"},
 	}
-	mockRepo.On("GetPaginatedLocations", 2, 0).Return(expected, nil)
+	mockRepo.On("GetPaginatedLocations", testifymock.Anything, 2, 0).Return(expected, nil)
 
-	locations, err := service.GetPaginatedLocations(2, 0)
+	locations, err := service.GetPaginatedLocations(context.Background(), 2, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, locations)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetPaginatedLocationsInBox(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+
+	box := geo.BoundingBox{MinLat: -20, MinLng: 170, MaxLat: -10, MaxLng: -170}
+	expected := []model.Location{
+		{ID: 4, Name: "Fiji", Latitude: -17.7, Longitude: 178.0, Color: "#00aaff"},
+	}
+	mockRepo.On("GetPaginatedLocationsInBox", testifymock.Anything, box, 10, 0).Return(expected, nil)
+
+	locations, err := service.GetPaginatedLocationsInBox(context.Background(), box, 10, 0)
 	assert.NoError(t, err)
 	assert.Equal(t, expected, locations)
 	mockRepo.AssertExpectations(t)
@@ -59,9 +137,9 @@ func TestGetLocationByID_Success(t *testing.T) {
 	service := NewLocationService(mockRepo)
 
 	expected := &model.Location{ID: 1, Name: "Loc", Latitude: 10, Longitude: 20, Color: "#ABCDEF"}
-	mockRepo.On("FindByID", uint(1)).Return(expected, nil)
+	mockRepo.On("FindByID", testifymock.Anything, uint(1)).Return(expected, nil)
 
-	location, err := service.GetLocationByID(1)
+	location, err := service.GetLocationByID(context.Background(), 1)
 	assert.NoError(t, err)
 	assert.Equal(t, expected, location)
 	mockRepo.AssertExpectations(t)
@@ -71,9 +149,9 @@ func TestGetLocationByID_NotFound(t *testing.T) {
 	mockRepo := new(mock.MockLocationRepository)
 	service := NewLocationService(mockRepo)
 
-	mockRepo.On("FindByID", uint(999)).Return(&model.Location{}, errors.New("not found"))
+	mockRepo.On("FindByID", testifymock.Anything, uint(999)).Return(&model.Location{}, errors.New("not found"))
 
-	_, err := service.GetLocationByID(999)
+	_, err := service.GetLocationByID(context.Background(), 999)
 	assert.Error(t, err)
 	mockRepo.AssertExpectations(t)
 }
@@ -83,10 +161,92 @@ func TestUpdateLocation(t *testing.T) {
 	service := NewLocationService(mockRepo)
 
 	location := &model.Location{ID: 1, Name: "Updated", Latitude: 11, Longitude: 22, Color: "#FF00FF"}
-	mockRepo.On("Update", location).Return(nil)
+	mockRepo.On("Update", testifymock.Anything, location).Return(nil)
+
+	err := service.UpdateLocation(context.Background(), location)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDeleteLocation(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+
+	mockRepo.On("Delete", testifymock.Anything, uint(1)).Return(nil)
+
+	err := service.DeleteLocation(context.Background(), 1)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDeleteLocation_NotFound(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+
+	mockRepo.On("Delete", testifymock.Anything, uint(999)).Return(errors.New("not found"))
+
+	err := service.DeleteLocation(context.Background(), 999)
+	assert.Error(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRestoreLocation(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+
+	expected := &model.Location{ID: 1, Name: "Restored", Latitude: 10, Longitude: 20, Color: "#ABCDEF"}
+	mockRepo.On("Restore", testifymock.Anything, uint(1)).Return(nil)
+	mockRepo.On("FindByID", testifymock.Anything, uint(1)).Return(expected, nil)
+
+	location, err := service.RestoreLocation(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, location)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPurgeDeletedLocations(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+
+	olderThan := 24 * time.Hour
+	mockRepo.On("PurgeDeleted", testifymock.Anything, testifymock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) >= olderThan && time.Since(before) < olderThan+time.Minute
+	})).Return(int64(3), nil)
+
+	purged, err := service.PurgeDeletedLocations(context.Background(), olderThan)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetNearbyLocations(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
 
-	err := service.UpdateLocation(location)
+	expected := []model.NearbyLocation{
+		{Location: model.Location{ID: 1, Name: "Near", Latitude: 41, Longitude: 29}, Distance: 0.5},
+	}
+	mockRepo.On("FindWithinRadius", testifymock.Anything, 41.0, 29.0, 5.0, 10, 0).Return(expected, nil)
+
+	locations, err := service.GetNearbyLocations(context.Background(), 41, 29, 5, 10, 0)
 	assert.NoError(t, err)
+	assert.Equal(t, expected, locations)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetNearestLocations(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+
+	expected := []model.NearbyLocation{
+		{Location: model.Location{ID: 2, Name: "Closest", Color: "#ff0000"}, Distance: 0.2},
+		{Location: model.Location{ID: 7, Name: "Second", Color: "#ff0000"}, Distance: 1.4},
+	}
+	mockRepo.On("FindNearest", testifymock.Anything, 41.0, 29.0, 2, "#ff0000").Return(expected, nil)
+
+	locations, err := service.GetNearestLocations(context.Background(), 41, 29, 2, "#ff0000")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, locations)
 	mockRepo.AssertExpectations(t)
 }
 
@@ -99,17 +259,528 @@ func TestGetRouteFrom(t *testing.T) {
 		{ID: 2, Name: "B", Latitude: 41.1, Longitude: 29.0},
 		{ID: 3, Name: "C", Latitude: 41.11, Longitude: 29.01},
 	}
-	mockRepo.On("FindAll").Return(mockLocations, nil)
+	mockRepo.On("FindAll", testifymock.Anything).Return(mockLocations, nil)
 
 	referenceLat := 41.11
 	referenceLng := 29.02
 
-	result, err := service.GetRouteFrom(referenceLat, referenceLng)
+	result, err := service.GetRouteFrom(context.Background(), referenceLat, referenceLng)
+
+	assert.NoError(t, err)
+	assert.Len(t, result.Stops, 3)
+	assert.Equal(t, "C", result.Stops[0].Location.Name)
+	assert.Equal(t, "B", result.Stops[1].Location.Name)
+	assert.Equal(t, "A", result.Stops[2].Location.Name)
+	assert.Greater(t, result.TotalDistance, 0.0)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetRouteFrom_FollowsPreviousStop(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+
+	// Sorting by distance from the reference point would zig-zag W1, E1, W2, E2.
+	mockLocations := []model.Location{
+		{ID: 1, Name: "E2", Latitude: 0, Longitude: 0.21},
+		{ID: 2, Name: "W1", Latitude: 0, Longitude: -0.1},
+		{ID: 3, Name: "E1", Latitude: 0, Longitude: 0.11},
+		{ID: 4, Name: "W2", Latitude: 0, Longitude: -0.2},
+	}
+	mockRepo.On("FindAll", testifymock.Anything).Return(mockLocations, nil)
+
+	result, err := service.GetRouteFrom(context.Background(), 0, 0)
+
+	assert.NoError(t, err)
+	names := make([]string, 0, len(result.Stops))
+	for _, loc := range result.Locations() {
+		names = append(names, loc.Name)
+	}
+	assert.Equal(t, []string{"W1", "W2", "E1", "E2"}, names)
+	assert.InDelta(t, geo.Haversine(0, 0, 0, 0.61), result.TotalDistance, 1e-6)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetRouteFrom_Empty(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+
+	mockRepo.On("FindAll", testifymock.Anything).Return([]model.Location{}, nil)
+
+	result, err := service.GetRouteFrom(context.Background(), 41, 29)
+
+	assert.NoError(t, err)
+	assert.Empty(t, result.Stops)
+	assert.Zero(t, result.TotalDistance)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetRouteFrom_Legs(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+
+	mockLocations := []model.Location{
+		{ID: 1, Name: "East", Latitude: 0, Longitude: 2},
+		{ID: 2, Name: "North", Latitude: 1, Longitude: 0},
+	}
+	mockRepo.On("FindAll", testifymock.Anything).Return(mockLocations, nil)
+
+	result, err := service.GetRouteFrom(context.Background(), 0, 0)
+
+	assert.NoError(t, err)
+	assert.Len(t, result.Stops, 2)
+
+	first, second := result.Stops[0], result.Stops[1]
+	assert.Equal(t, "North", first.Location.Name)
+	assert.InDelta(t, geo.Haversine(0, 0, 1, 0), first.LegDistance, 1e-9)
+	assert.InDelta(t, 0, first.Bearing, 1e-9)
+
+	assert.Equal(t, "East", second.Location.Name)
+	assert.InDelta(t, geo.Haversine(1, 0, 0, 2), second.LegDistance, 1e-9)
+	assert.InDelta(t, first.LegDistance+second.LegDistance, second.CumulativeDistance, 1e-9)
+	assert.InDelta(t, second.CumulativeDistance, result.TotalDistance, 1e-9)
+	assert.Greater(t, second.Bearing, 90.0)
+	assert.Less(t, second.Bearing, 180.0)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetRouteFrom_ServesCachedRoute(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo, WithCache(cache.NewMemoryCache(16)))
+	mockRepo.On("DataVersion", testifymock.Anything).Return(int64(0), nil).Maybe()
+
+	mockRepo.On("FindAll", testifymock.Anything).Return([]model.Location{
+		{ID: 1, Name: "A", Latitude: 41.0, Longitude: 29.0},
+	}, nil).Once()
+
+	first, err := service.GetRouteFrom(context.Background(), 41, 29)
+	assert.NoError(t, err)
+	second, err := service.GetRouteFrom(context.Background(), 41, 29)
+	assert.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	mockRepo.AssertNumberOfCalls(t, "FindAll", 1)
+}
+
+func TestGetRouteFrom_CreateInvalidatesCachedRoute(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo, WithCache(cache.NewMemoryCache(16)))
+	mockRepo.On("DataVersion", testifymock.Anything).Return(int64(0), nil).Maybe()
+
+	existing := model.Location{ID: 1, Name: "A", Latitude: 41.0, Longitude: 29.0}
+	created := &model.Location{ID: 2, Name: "B", Latitude: 41.1, Longitude: 29.1, Color: "#FFFFFF"}
+
+	mockRepo.On("FindAll", testifymock.Anything).Return([]model.Location{existing}, nil).Once()
+	mockRepo.On("Create", testifymock.Anything, created).Return(nil)
+	mockRepo.On("FindAll", testifymock.Anything).Return([]model.Location{existing, *created}, nil).Once()
+
+	before, err := service.GetRouteFrom(context.Background(), 41, 29)
+	assert.NoError(t, err)
+	assert.Len(t, before.Stops, 1)
+
+	assert.NoError(t, service.CreateLocation(context.Background(), created))
+
+	after, err := service.GetRouteFrom(context.Background(), 41, 29)
+	assert.NoError(t, err)
+	assert.Len(t, after.Stops, 2)
+	assert.Equal(t, "B", after.Stops[1].Location.Name)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetRouteFrom_UpdateAndDeleteInvalidateCachedRoute(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo, WithCache(cache.NewMemoryCache(16)))
+	mockRepo.On("DataVersion", testifymock.Anything).Return(int64(0), nil).Maybe()
+
+	location := &model.Location{ID: 1, Name: "A", Latitude: 41.0, Longitude: 29.0}
+	mockRepo.On("FindAll", testifymock.Anything).Return([]model.Location{*location}, nil).Once()
+	mockRepo.On("Update", testifymock.Anything, location).Return(nil)
+	mockRepo.On("FindAll", testifymock.Anything).Return([]model.Location{{ID: 1, Name: "Renamed", Latitude: 41.0, Longitude: 29.0}}, nil).Once()
+	mockRepo.On("Delete", testifymock.Anything, uint(1)).Return(nil)
+	mockRepo.On("FindAll", testifymock.Anything).Return([]model.Location{}, nil).Once()
+
+	_, err := service.GetRouteFrom(context.Background(), 41, 29)
+	assert.NoError(t, err)
+
+	assert.NoError(t, service.UpdateLocation(context.Background(), location))
+	renamed, err := service.GetRouteFrom(context.Background(), 41, 29)
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed", renamed.Stops[0].Location.Name)
+
+	assert.NoError(t, service.DeleteLocation(context.Background(), 1))
+	empty, err := service.GetRouteFrom(context.Background(), 41, 29)
+	assert.NoError(t, err)
+	assert.Empty(t, empty.Stops)
+	mockRepo.AssertExpectations(t)
+}
+
+// failingIncrCache fails the given number of increments, such as dataset
+// generation bumps, before passing them through.
+type failingIncrCache struct {
+	cache.Cache
+	failures int
+}
+
+func (c *failingIncrCache) Incr(ctx context.Context, key string) (int64, error) {
+	if c.failures > 0 {
+		c.failures--
+		return 0, errors.New("connection refused")
+	}
+	return c.Cache.Incr(ctx, key)
+}
+
+func TestGetRouteFrom_RetriesFailedInvalidation(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	routeCache := &failingIncrCache{Cache: cache.NewMemoryCache(16)}
+	service := NewLocationService(mockRepo, WithCache(routeCache))
+	mockRepo.On("DataVersion", testifymock.Anything).Return(int64(0), nil).Maybe()
+
+	existing := model.Location{ID: 1, Name: "A", Latitude: 41.0, Longitude: 29.0}
+	created := &model.Location{ID: 2, Name: "B", Latitude: 41.1, Longitude: 29.1, Color: "#FFFFFF"}
+	mockRepo.On("FindAll", testifymock.Anything).Return([]model.Location{existing}, nil).Once()
+	mockRepo.On("Create", testifymock.Anything, created).Return(nil)
+	mockRepo.On("FindAll", testifymock.Anything).Return([]model.Location{existing, *created}, nil)
+
+	_, err := service.GetRouteFrom(context.Background(), 41, 29)
+	assert.NoError(t, err)
+
+	// the bump after the write and its first retry fail
+	routeCache.failures = 2
+	assert.NoError(t, service.CreateLocation(context.Background(), created))
+
+	bypassed, err := service.GetRouteFrom(context.Background(), 41, 29)
+	assert.NoError(t, err)
+	assert.Len(t, bypassed.Stops, 2, "the stale route is not served while the bump is pending")
+
+	retried, err := service.GetRouteFrom(context.Background(), 41, 29)
+	assert.NoError(t, err)
+	assert.Len(t, retried.Stops, 2)
+
+	cached, err := service.GetRouteFrom(context.Background(), 41, 29)
+	assert.NoError(t, err)
+	assert.Equal(t, retried, cached)
+	mockRepo.AssertNumberOfCalls(t, "FindAll", 3)
+}
+
+func TestGetRouteFrom_CoalescesConcurrentRequests(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo, WithCache(cache.NewMemoryCache(16)))
+	mockRepo.On("DataVersion", testifymock.Anything).Return(int64(0), nil).Maybe()
+
+	release := make(chan struct{})
+	mockRepo.On("FindAll", testifymock.Anything).Run(func(testifymock.Arguments) {
+		<-release
+	}).Return([]model.Location{
+		{ID: 1, Name: "A", Latitude: 41.0, Longitude: 29.0},
+	}, nil)
+
+	const callers = 20
+	var wg sync.WaitGroup
+	results := make([]*model.Route, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			route, err := service.GetRouteFrom(context.Background(), 41, 29)
+			assert.NoError(t, err)
+			results[i] = route
+		}(i)
+	}
+
+	// let every caller reach the flight before the computation finishes
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	mockRepo.AssertNumberOfCalls(t, "FindAll", 1)
+	for _, route := range results {
+		assert.Len(t, route.Stops, 1)
+	}
+}
+
+func TestGetRouteFrom_ServesStaleWhileRevalidating(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	svc := NewLocationService(mockRepo, WithCache(cache.NewMemoryCache(16))).(*locationService)
+	mockRepo.On("DataVersion", testifymock.Anything).Return(int64(0), nil).Maybe()
+
+	now := time.Now()
+	var mu sync.Mutex
+	svc.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+
+	refreshed := make(chan struct{})
+	mockRepo.On("FindAll", testifymock.Anything).Return([]model.Location{
+		{ID: 1, Name: "Old", Latitude: 41.0, Longitude: 29.0},
+	}, nil).Once()
+	mockRepo.On("FindAll", testifymock.Anything).Run(func(testifymock.Arguments) {
+		close(refreshed)
+	}).Return([]model.Location{
+		{ID: 1, Name: "New", Latitude: 41.0, Longitude: 29.0},
+	}, nil).Once()
+
+	first, err := svc.GetRouteFrom(context.Background(), 41, 29)
+	assert.NoError(t, err)
+	assert.Equal(t, "Old", first.Stops[0].Location.Name)
+
+	mu.Lock()
+	now = now.Add(routeCacheTTL + time.Second)
+	mu.Unlock()
+
+	stale, err := svc.GetRouteFrom(context.Background(), 41, 29)
+	assert.NoError(t, err)
+	assert.Equal(t, "Old", stale.Stops[0].Location.Name, "an expired route is served while it is refreshed")
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("background refresh did not run")
+	}
+
+	assert.Eventually(t, func() bool {
+		route, err := svc.GetRouteFrom(context.Background(), 41, 29)
+		return err == nil && route.Stops[0].Location.Name == "New"
+	}, time.Second, 10*time.Millisecond)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetNearbyLocations_PassesContextToRepository(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	mockRepo.On("FindWithinRadius", testifymock.MatchedBy(func(got context.Context) bool {
+		return got.Value(ctxKey{}) == "request"
+	}), 41.0, 29.0, 5.0, 10, 0).Return([]model.NearbyLocation{}, nil)
+
+	_, err := service.GetNearbyLocations(ctx, 41, 29, 5, 10, 0)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetRouteFrom_CancelledCallerDoesNotAbortSharedComputation(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo, WithCache(cache.NewMemoryCache(16)))
+	mockRepo.On("DataVersion", testifymock.Anything).Return(int64(0), nil).Maybe()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mockRepo.On("FindAll", testifymock.Anything).Run(func(args testifymock.Arguments) {
+		close(started)
+		<-release
+		assert.NoError(t, args.Get(0).(context.Context).Err(), "shared computation must outlive its first caller")
+	}).Return([]model.Location{
+		{ID: 1, Name: "A", Latitude: 41.0, Longitude: 29.0},
+	}, nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := service.GetRouteFrom(ctx, 41, 29)
+		cancelled <- err
+	}()
+
+	<-started
+	cancel()
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		route, err := service.GetRouteFrom(context.Background(), 41, 29)
+		return err == nil && len(route.Stops) == 1
+	}, time.Second, 10*time.Millisecond)
+	mockRepo.AssertExpectations(t)
+}
+
+// depotLocations are four stops on a line east of a depot at the origin.
+var depotLocations = []model.Location{
+	{ID: 1, Name: "Far", Latitude: 0, Longitude: 0.3},
+	{ID: 2, Name: "Depot", Latitude: 0, Longitude: 0},
+	{ID: 3, Name: "Near", Latitude: 0, Longitude: 0.1},
+	{ID: 4, Name: "Middle", Latitude: 0, Longitude: 0.2},
+}
+
+func stopNames(route *model.Route) []string {
+	names := make([]string, 0, len(route.Stops))
+	for _, loc := range route.Locations() {
+		names = append(names, loc.Name)
+	}
+	return names
+}
+
+func TestGetRoute_StartsAtLocation(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+	mockRepo.On("FindAll", testifymock.Anything).Return(depotLocations, nil)
+
+	result, err := service.GetRoute(context.Background(), RouteOptions{StartID: 2})
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, result.Start) {
+		assert.Equal(t, "Depot", result.Start.Name)
+	}
+	assert.Equal(t, []string{"Near", "Middle", "Far"}, stopNames(result), "the start location is not a stop")
+	assert.InDelta(t, geo.Haversine(0, 0, 0, 0.3), result.TotalDistance, 1e-6)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetRoute_ReturnsToStart(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+	mockRepo.On("FindAll", testifymock.Anything).Return(depotLocations, nil)
+
+	closed, err := service.GetRoute(context.Background(), RouteOptions{StartID: 2, ReturnToStart: true})
+	assert.NoError(t, err)
+	names := stopNames(closed)
+	assert.Len(t, names, 4)
+	assert.Equal(t, "Depot", names[3], "a closed route ends back at the start")
+	assert.InDelta(t, 2*geo.Haversine(0, 0, 0, 0.3), closed.TotalDistance, 1e-6)
+
+	sameEnd, err := service.GetRoute(context.Background(), RouteOptions{StartID: 2, EndID: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, closed, sameEnd, "ending at the start is a closed route")
+}
+
+func TestGetRoute_EndsAtLocation(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+	mockRepo.On("FindAll", testifymock.Anything).Return(depotLocations, nil)
+
+	fromDepot, err := service.GetRoute(context.Background(), RouteOptions{StartID: 2, EndID: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Near", "Far", "Middle"}, stopNames(fromDepot))
+
+	fromPoint, err := service.GetRoute(context.Background(), RouteOptions{Lat: 0, Lng: -0.1, EndID: 2})
+	assert.NoError(t, err)
+	names := stopNames(fromPoint)
+	assert.Len(t, names, 4)
+	assert.Equal(t, "Depot", names[3])
+	assert.Nil(t, fromPoint.Start)
+}
+
+func TestGetRoute_UnknownAnchor(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+	mockRepo.On("FindAll", testifymock.Anything).Return(depotLocations, nil)
+
+	_, err := service.GetRoute(context.Background(), RouteOptions{StartID: 99})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.ErrorContains(t, err, "start location 99")
+
+	_, err = service.GetRoute(context.Background(), RouteOptions{StartID: 2, EndID: 98})
+	assert.ErrorContains(t, err, "end location 98")
+}
+
+func TestGetRoute_RejectsContradictingOptions(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+
+	_, err := service.GetRoute(context.Background(), RouteOptions{Lat: 41, Lng: 29, ReturnToStart: true})
+	assert.ErrorIs(t, err, ErrInvalidRouteOptions)
+
+	_, err = service.GetRoute(context.Background(), RouteOptions{StartID: 1, EndID: 2, ReturnToStart: true})
+	assert.ErrorIs(t, err, ErrInvalidRouteOptions)
+	mockRepo.AssertNotCalled(t, "FindAll", testifymock.Anything)
+}
+
+func TestGetRoute_CachesEachAnchoringSeparately(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo, WithCache(cache.NewMemoryCache(16)))
+	mockRepo.On("DataVersion", testifymock.Anything).Return(int64(0), nil).Maybe()
+	mockRepo.On("FindAll", testifymock.Anything).Return(depotLocations, nil).Times(2)
+
+	open, err := service.GetRoute(context.Background(), RouteOptions{StartID: 2})
+	assert.NoError(t, err)
+	closed, err := service.GetRoute(context.Background(), RouteOptions{StartID: 2, ReturnToStart: true})
+	assert.NoError(t, err)
+	assert.NotEqual(t, len(open.Stops), len(closed.Stops))
+
+	cached, err := service.GetRoute(context.Background(), RouteOptions{StartID: 2, EndID: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, closed, cached)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetRoute_SelectedLocations(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+	mockRepo.On("FindByIDs", testifymock.Anything, []uint{1, 3, 4}).Return([]model.Location{depotLocations[0], depotLocations[2], depotLocations[3]}, nil)
+
+	result, err := service.GetRoute(context.Background(), RouteOptions{LocationIDs: []uint{4, 1, 3, 1}, Lat: 0, Lng: 0})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Near", "Middle", "Far"}, stopNames(result))
+	mockRepo.AssertNotCalled(t, "FindAll", testifymock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetRoute_MissingSelectedLocations(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+	mockRepo.On("FindByIDs", testifymock.Anything, []uint{1, 5, 7}).Return([]model.Location{depotLocations[0]}, nil)
+
+	_, err := service.GetRoute(context.Background(), RouteOptions{LocationIDs: []uint{7, 5, 1}})
+
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.ErrorContains(t, err, "locations [5 7]")
+}
+
+func TestGetRoute_SelectedColors(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+	red := []model.Location{
+		{ID: 1, Name: "Red", Latitude: 0, Longitude: 0.1, Color: "#FF0000"},
+		{ID: 2, Name: "Blue", Latitude: 0, Longitude: 0.2, Color: "#0000ff"},
+	}
+	mockRepo.On("FindByColors", testifymock.Anything, []string{"#0000ff", "#ff0000"}).Return(red, nil)
+	mockRepo.On("FindByIDs", testifymock.Anything, []uint{1, 2}).Return(red, nil)
+
+	byColor, err := service.GetRoute(context.Background(), RouteOptions{Colors: []string{"#FF0000", "#0000ff"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Red", "Blue"}, stopNames(byColor))
+
+	both, err := service.GetRoute(context.Background(), RouteOptions{LocationIDs: []uint{1, 2}, Colors: []string{"#ff0000"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Red"}, stopNames(both), "IDs and colors must both match")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetRoute_AnchorOutsideSelection(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo)
+	mockRepo.On("FindByIDs", testifymock.Anything, []uint{1, 4}).Return([]model.Location{depotLocations[0], depotLocations[3]}, nil)
+	mockRepo.On("FindByID", testifymock.Anything, uint(2)).Return(&depotLocations[1], nil)
+	mockRepo.On("FindByID", testifymock.Anything, uint(9)).Return((*model.Location)(nil), gorm.ErrRecordNotFound)
+
+	result, err := service.GetRoute(context.Background(), RouteOptions{LocationIDs: []uint{1, 4}, StartID: 2, ReturnToStart: true})
+	assert.NoError(t, err)
+	if assert.NotNil(t, result.Start) {
+		assert.Equal(t, "Depot", result.Start.Name)
+	}
+	assert.Equal(t, []string{"Middle", "Far", "Depot"}, stopNames(result))
+
+	_, err = service.GetRoute(context.Background(), RouteOptions{LocationIDs: []uint{1, 4}, StartID: 2, EndID: 9})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.ErrorContains(t, err, "end location 9")
+}
+
+func TestGetRoute_CachesSelectionsRegardlessOfOrder(t *testing.T) {
+	mockRepo := new(mock.MockLocationRepository)
+	service := NewLocationService(mockRepo, WithCache(cache.NewMemoryCache(16)))
+	mockRepo.On("DataVersion", testifymock.Anything).Return(int64(0), nil).Maybe()
+	mockRepo.On("FindByIDs", testifymock.Anything, []uint{1, 3}).Return([]model.Location{depotLocations[0], depotLocations[2]}, nil).Once()
+	mockRepo.On("FindByIDs", testifymock.Anything, []uint{1, 4}).Return([]model.Location{depotLocations[0], depotLocations[3]}, nil).Once()
+
+	first, err := service.GetRoute(context.Background(), RouteOptions{LocationIDs: []uint{3, 1}})
+	assert.NoError(t, err)
+	cached, err := service.GetRoute(context.Background(), RouteOptions{LocationIDs: []uint{1, 3, 3}})
+	assert.NoError(t, err)
+	assert.Equal(t, first, cached)
 
+	other, err := service.GetRoute(context.Background(), RouteOptions{LocationIDs: []uint{1, 4}})
 	assert.NoError(t, err)
-	assert.Len(t, result, 3)
-	assert.Equal(t, "C", result[0].Name)
-	assert.Equal(t, "B", result[1].Name)
-	assert.Equal(t, "A", result[2].Name)
+	assert.Equal(t, []string{"Middle", "Far"}, stopNames(other))
 	mockRepo.AssertExpectations(t)
 }
@@ -0,0 +1,249 @@
+package service
+
+import "github.com/yusufbulac/location-routing-service/internal/geo"
+
+const (
+	// maxImprovementPasses bounds the number of 2-opt / Or-opt sweeps over a tour.
+	maxImprovementPasses = 50
+	// maxImprovedStops is the largest tour that is refined after construction;
+	// bigger tours keep their nearest-neighbour order to bound response time.
+	maxImprovedStops = 2000
+	// maxMatrixStops is the largest tour for which pairwise distances are precomputed.
+	maxMatrixStops = 1500
+	// orOptMaxSegment is the longest run of consecutive stops relocated by Or-opt.
+	orOptMaxSegment = 3
+
+	improvementEpsilon = 1e-9
+)
+
+type geoPoint struct {
+	Lat float64
+	Lng float64
+}
+
+type tourOptions struct {
+	// Start is the index of the point the tour begins at.
+	Start int
+	// End is the index of the point the tour must finish at, or -1 for a free end.
+	End int
+	// Closed makes the tour return to Start after the last stop.
+	Closed bool
+}
+
+// tour is a visiting order over a fixed set of points.
+type tour struct {
+	order    []int
+	dist     func(a, b int) float64
+	closed   bool
+	fixedEnd bool
+}
+
+// optimizeTour returns a visiting order over points (as indexes into points)
+// built by nearest-neighbour construction and refined with 2-opt and Or-opt.
+func optimizeTour(points []geoPoint, opts tourOptions) []int {
+	if len(points) == 0 {
+		return nil
+	}
+
+	t := &tour{
+		dist:     distanceFunc(points),
+		closed:   opts.Closed,
+		fixedEnd: !opts.Closed && opts.End >= 0 && opts.End != opts.Start,
+	}
+	t.order = nearestNeighbour(len(points), t.dist, opts.Start, opts.End, t.fixedEnd)
+
+	if len(t.order) <= maxImprovedStops {
+		t.improve()
+	}
+	return t.order
+}
+
+func distanceFunc(points []geoPoint) func(a, b int) float64 {
+	n := len(points)
+	if n > maxMatrixStops {
+		return func(a, b int) float64 {
+			return geo.Haversine(points[a].Lat, points[a].Lng, points[b].Lat, points[b].Lng)
+		}
+	}
+
+	matrix := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			d := geo.Haversine(points[i].Lat, points[i].Lng, points[j].Lat, points[j].Lng)
+			matrix[i*n+j] = d
+			matrix[j*n+i] = d
+		}
+	}
+	return func(a, b int) float64 {
+		return matrix[a*n+b]
+	}
+}
+
+func nearestNeighbour(n int, dist func(a, b int) float64, start, end int, fixedEnd bool) []int {
+	visited := make([]bool, n)
+	order := make([]int, 0, n)
+
+	visited[start] = true
+	order = append(order, start)
+	if fixedEnd {
+		visited[end] = true
+	}
+
+	current := start
+	remaining := n - 1
+	if fixedEnd {
+		remaining--
+	}
+
+	for ; remaining > 0; remaining-- {
+		next := -1
+		best := 0.0
+		for i := 0; i < n; i++ {
+			if visited[i] {
+				continue
+			}
+			if d := dist(current, i); next == -1 || d < best {
+				next, best = i, d
+			}
+		}
+		visited[next] = true
+		order = append(order, next)
+		current = next
+	}
+
+	if fixedEnd {
+		order = append(order, end)
+	}
+	return order
+}
+
+func (t *tour) improve() {
+	for pass := 0; pass < maxImprovementPasses; pass++ {
+		improved := t.twoOpt()
+		if t.orOpt() {
+			improved = true
+		}
+		if !improved {
+			return
+		}
+	}
+}
+
+// lastMovable is the highest position whose stop may be moved.
+func (t *tour) lastMovable() int {
+	if t.fixedEnd {
+		return len(t.order) - 2
+	}
+	return len(t.order) - 1
+}
+
+// successor returns the stop following position i, or -1 when the path ends there.
+func (t *tour) successor(i int) int {
+	if i+1 < len(t.order) {
+		return t.order[i+1]
+	}
+	if t.closed {
+		return t.order[0]
+	}
+	return -1
+}
+
+// edge returns the distance between a and b, treating a missing endpoint as free.
+func (t *tour) edge(a, b int) float64 {
+	if a < 0 || b < 0 {
+		return 0
+	}
+	return t.dist(a, b)
+}
+
+// twoOpt reverses segments of the tour while doing so shortens it.
+func (t *tour) twoOpt() bool {
+	improved := false
+	last := t.lastMovable()
+
+	for i := 1; i < last; i++ {
+		for j := i + 1; j <= last; j++ {
+			a, b := t.order[i-1], t.order[i]
+			c, d := t.order[j], t.successor(j)
+
+			delta := t.edge(a, c) + t.edge(b, d) - t.edge(a, b) - t.edge(c, d)
+			if delta < -improvementEpsilon {
+				reverse(t.order[i : j+1])
+				improved = true
+			}
+		}
+	}
+	return improved
+}
+
+// orOpt relocates short runs of consecutive stops, optionally reversed, to
+// the position where they lengthen the tour the least.
+func (t *tour) orOpt() bool {
+	improved := false
+	last := t.lastMovable()
+
+	for length := 1; length <= orOptMaxSegment; length++ {
+		for i := 1; i+length-1 <= last; i++ {
+			j := i + length - 1
+			first, tail := t.order[i], t.order[j]
+			prev, next := t.order[i-1], t.successor(j)
+
+			removeGain := t.edge(prev, first) + t.edge(tail, next) - t.edge(prev, next)
+
+			bestPos, bestReversed, bestDelta := -1, false, -improvementEpsilon
+			for k := 0; k < len(t.order); k++ {
+				if k >= i-1 && k <= j {
+					continue
+				}
+				if k == len(t.order)-1 && t.fixedEnd {
+					continue
+				}
+				x, y := t.order[k], t.successor(k)
+
+				forward := t.edge(x, first) + t.edge(tail, y) - t.edge(x, y) - removeGain
+				if forward < bestDelta {
+					bestPos, bestReversed, bestDelta = k, false, forward
+				}
+				backward := t.edge(x, tail) + t.edge(first, y) - t.edge(x, y) - removeGain
+				if backward < bestDelta {
+					bestPos, bestReversed, bestDelta = k, true, backward
+				}
+			}
+
+			if bestPos >= 0 {
+				t.moveSegment(i, j, bestPos, bestReversed)
+				improved = true
+			}
+		}
+	}
+	return improved
+}
+
+// moveSegment moves order[i..j] to just after the stop currently at position k.
+func (t *tour) moveSegment(i, j, k int, reversed bool) {
+	segment := append([]int(nil), t.order[i:j+1]...)
+	if reversed {
+		reverse(segment)
+	}
+
+	rest := make([]int, 0, len(t.order)-len(segment))
+	rest = append(rest, t.order[:i]...)
+	rest = append(rest, t.order[j+1:]...)
+
+	insertAt := k + 1
+	if k > j {
+		insertAt -= len(segment)
+	}
+
+	order := make([]int, 0, len(t.order))
+	order = append(order, rest[:insertAt]...)
+	order = append(order, segment...)
+	order = append(order, rest[insertAt:]...)
+	t.order = order
+}
+
+func reverse(s []int) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yusufbulac/location-routing-service/internal/geo"
+)
+
+func randomPoints(n int, seed int64) []geoPoint {
+	rng := rand.New(rand.NewSource(seed))
+	points := make([]geoPoint, n)
+	for i := range points {
+		points[i] = geoPoint{Lat: 40 + rng.Float64(), Lng: 28 + rng.Float64()}
+	}
+	return points
+}
+
+// tourLength returns the length of the given visiting order in kilometres.
+func tourLength(points []geoPoint, order []int, closed bool) float64 {
+	total := 0.0
+	for i := 1; i < len(order); i++ {
+		a, b := points[order[i-1]], points[order[i]]
+		total += geo.Haversine(a.Lat, a.Lng, b.Lat, b.Lng)
+	}
+	if closed && len(order) > 1 {
+		a, b := points[order[len(order)-1]], points[order[0]]
+		total += geo.Haversine(a.Lat, a.Lng, b.Lat, b.Lng)
+	}
+	return total
+}
+
+func assertPermutation(t *testing.T, order []int, n int) {
+	t.Helper()
+	assert.Len(t, order, n)
+	seen := make(map[int]bool, n)
+	for _, idx := range order {
+		assert.False(t, seen[idx], "index %d visited twice", idx)
+		seen[idx] = true
+	}
+}
+
+func TestOptimizeTour_ImprovesOnNearestNeighbour(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		points := randomPoints(60, seed)
+		opts := tourOptions{Start: 0, End: -1}
+
+		dist := distanceFunc(points)
+		constructed := nearestNeighbour(len(points), dist, 0, -1, false)
+		optimized := optimizeTour(points, opts)
+
+		assertPermutation(t, optimized, len(points))
+		assert.Equal(t, 0, optimized[0])
+		assert.LessOrEqual(t, tourLength(points, optimized, false), tourLength(points, constructed, false)+1e-9)
+	}
+}
+
+func TestOptimizeTour_RemovesCrossing(t *testing.T) {
+	// A square visited corner to opposite corner crosses itself.
+	points := []geoPoint{{0, 0}, {0, 1}, {1, 1}, {1, 0}}
+	tr := &tour{order: []int{0, 2, 1, 3}, dist: distanceFunc(points), closed: true}
+
+	tr.improve()
+
+	assert.InDelta(t, tourLength(points, []int{0, 1, 2, 3}, true), tourLength(points, tr.order, true), 1e-9)
+}
+
+func TestOptimizeTour_RespectsAnchors(t *testing.T) {
+	points := randomPoints(40, 42)
+
+	open := optimizeTour(points, tourOptions{Start: 5, End: 17})
+	assertPermutation(t, open, len(points))
+	assert.Equal(t, 5, open[0])
+	assert.Equal(t, 17, open[len(open)-1])
+
+	closed := optimizeTour(points, tourOptions{Start: 3, End: -1, Closed: true})
+	assertPermutation(t, closed, len(points))
+	assert.Equal(t, 3, closed[0])
+}
+
+func TestOptimizeTour_SinglePoint(t *testing.T) {
+	order := optimizeTour([]geoPoint{{41, 29}}, tourOptions{Start: 0, End: -1})
+	assert.Equal(t, []int{0}, order)
+	assert.Zero(t, tourLength([]geoPoint{{41, 29}}, order, false))
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidRouteOptions is returned for route options that contradict each other.
+var ErrInvalidRouteOptions = errors.New("invalid route options")
+
+// RouteOptions selects the locations of a route and where it starts and ends.
+// A route starts at the reference point (Lat, Lng) unless StartID names a
+// location to start from, such as a depot. Every other selected location is
+// visited once.
+type RouteOptions struct {
+	// LocationIDs restricts the route to these locations; empty selects all.
+	LocationIDs []uint
+	// Colors restricts the route to locations with one of these marker
+	// colors, compared case-insensitively; empty selects all. Combined with
+	// LocationIDs, a location must match both.
+	Colors []string
+	Lat    float64
+	Lng    float64
+	// StartID is the location the route starts at; zero starts at (Lat, Lng).
+	StartID uint
+	// EndID is the location the route must finish at; zero leaves the end open.
+	EndID uint
+	// ReturnToStart closes the route with a last stop back at StartID.
+	ReturnToStart bool
+}
+
+// Validate reports contradicting options. An EndID equal to StartID is the
+// same as ReturnToStart.
+func (o RouteOptions) Validate() error {
+	if o.ReturnToStart && o.StartID == 0 {
+		return fmt.Errorf("%w: returning to the start needs a start location", ErrInvalidRouteOptions)
+	}
+	if o.ReturnToStart && o.EndID != 0 && o.EndID != o.StartID {
+		return fmt.Errorf("%w: a route returning to the start cannot end at another location", ErrInvalidRouteOptions)
+	}
+	return nil
+}
+
+// selective reports whether the route covers a subset of the locations.
+func (o RouteOptions) selective() bool {
+	return len(o.LocationIDs) > 0 || len(o.Colors) > 0
+}
+
+// normalize folds an end at the start location into ReturnToStart and sorts
+// the selection without duplicates, so equal selections share a cache key.
+func (o RouteOptions) normalize() RouteOptions {
+	if len(o.LocationIDs) > 0 {
+		ids := slices.Clone(o.LocationIDs)
+		slices.Sort(ids)
+		o.LocationIDs = slices.Compact(ids)
+	}
+	if len(o.Colors) > 0 {
+		colors := make([]string, len(o.Colors))
+		for i, color := range o.Colors {
+			colors[i] = strings.ToLower(color)
+		}
+		slices.Sort(colors)
+		o.Colors = slices.Compact(colors)
+	}
+	if o.StartID != 0 && o.EndID == o.StartID {
+		o.EndID, o.ReturnToStart = 0, true
+	}
+	if o.StartID != 0 {
+		o.Lat, o.Lng = 0, 0
+	}
+	return o
+}
+
+// cacheKey identifies the route within a dataset generation. Routes from a
+// reference point over every location keep the key format used before
+// anchors existed; selections are hashed to bound the key length.
+func (o RouteOptions) cacheKey() string {
+	key := fmt.Sprintf("%.4f:%.4f", o.Lat, o.Lng)
+	if o.StartID != 0 {
+		key = fmt.Sprintf("start=%d", o.StartID)
+	}
+	if o.EndID != 0 {
+		key += fmt.Sprintf(":end=%d", o.EndID)
+	}
+	if o.ReturnToStart {
+		key += ":closed"
+	}
+	if o.selective() {
+		h := sha256.New()
+		fmt.Fprint(h, o.LocationIDs, o.Colors)
+		key += ":select=" + hex.EncodeToString(h.Sum(nil)[:12])
+	}
+	return key
+}
@@ -0,0 +1,222 @@
+package spatial
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yusufbulac/location-routing-service/internal/geo"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+)
+
+// DefaultCellSize is the grid cell edge in degrees (roughly 11 km at the equator).
+const DefaultCellSize = 0.1
+
+type cell struct {
+	x, y int
+}
+
+// Index is an in-memory uniform grid over location coordinates. It is safe
+// for concurrent use. Locations are stored by value, so callers must call
+// Upsert again after modifying a location.
+type Index struct {
+	mu       sync.RWMutex
+	cellSize float64
+	cells    map[cell]map[uint]model.Location
+	byID     map[uint]cell
+}
+
+// NewIndex creates an empty index whose grid cells are cellSize degrees wide.
+func NewIndex(cellSize float64) *Index {
+	if cellSize <= 0 {
+		cellSize = DefaultCellSize
+	}
+	return &Index{
+		cellSize: cellSize,
+		cells:    make(map[cell]map[uint]model.Location),
+		byID:     make(map[uint]cell),
+	}
+}
+
+// Load replaces the index contents with the given locations.
+func (ix *Index) Load(locations []model.Location) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.cells = make(map[cell]map[uint]model.Location)
+	ix.byID = make(map[uint]cell, len(locations))
+	for _, loc := range locations {
+		ix.insert(loc)
+	}
+}
+
+// Upsert adds the location or moves it to its new coordinates.
+func (ix *Index) Upsert(loc model.Location) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.remove(loc.ID)
+	ix.insert(loc)
+}
+
+// Remove drops the location with the given ID, if present.
+func (ix *Index) Remove(id uint) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.remove(id)
+}
+
+// Len returns the number of indexed locations.
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return len(ix.byID)
+}
+
+// All returns every indexed location in ID order.
+func (ix *Index) All() []model.Location {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	locations := make([]model.Location, 0, len(ix.byID))
+	for _, bucket := range ix.cells {
+		for _, loc := range bucket {
+			locations = append(locations, loc)
+		}
+	}
+	sortByID(locations)
+	return locations
+}
+
+// InBox returns a page of the locations inside box in ID order.
+func (ix *Index) InBox(box geo.BoundingBox, limit, offset int) []model.Location {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	locations := ix.inBox(box)
+	sortByID(locations)
+	return geo.Paginate(locations, limit, offset)
+}
+
+// WithinRadius returns a page of the locations within radiusKm of the point,
+// ordered by distance and then ID.
+func (ix *Index) WithinRadius(lat, lng, radiusKm float64, limit, offset int) []model.NearbyLocation {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return geo.Paginate(ix.withinRadius(lat, lng, radiusKm, ""), limit, offset)
+}
+
+// Nearest returns the k locations closest to the point. A non-empty color
+// restricts the search to locations with that marker color.
+func (ix *Index) Nearest(lat, lng float64, k int, color string) []model.NearbyLocation {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	nearby, _ := geo.SearchNearest(k, func(radiusKm float64) ([]model.NearbyLocation, error) {
+		return ix.withinRadius(lat, lng, radiusKm, color), nil
+	})
+	return nearby
+}
+
+func (ix *Index) cellOf(lat, lng float64) cell {
+	return cell{
+		x: int(math.Floor((lng + 180) / ix.cellSize)),
+		y: int(math.Floor((lat + 90) / ix.cellSize)),
+	}
+}
+
+func (ix *Index) insert(loc model.Location) {
+	c := ix.cellOf(loc.Latitude, loc.Longitude)
+	bucket, ok := ix.cells[c]
+	if !ok {
+		bucket = make(map[uint]model.Location)
+		ix.cells[c] = bucket
+	}
+	bucket[loc.ID] = loc
+	ix.byID[loc.ID] = c
+}
+
+func (ix *Index) remove(id uint) {
+	c, ok := ix.byID[id]
+	if !ok {
+		return
+	}
+	delete(ix.byID, id)
+
+	bucket := ix.cells[c]
+	delete(bucket, id)
+	if len(bucket) == 0 {
+		delete(ix.cells, c)
+	}
+}
+
+func (ix *Index) inBox(box geo.BoundingBox) []model.Location {
+	if box.CrossesAntimeridian() {
+		east := geo.BoundingBox{MinLat: box.MinLat, MinLng: box.MinLng, MaxLat: box.MaxLat, MaxLng: 180}
+		west := geo.BoundingBox{MinLat: box.MinLat, MinLng: -180, MaxLat: box.MaxLat, MaxLng: box.MaxLng}
+		return append(ix.inBox(east), ix.inBox(west)...)
+	}
+
+	var locations []model.Location
+	collect := func(bucket map[uint]model.Location) {
+		for _, loc := range bucket {
+			if box.Contains(loc.Latitude, loc.Longitude) {
+				locations = append(locations, loc)
+			}
+		}
+	}
+
+	lo := ix.cellOf(box.MinLat, box.MinLng)
+	hi := ix.cellOf(box.MaxLat, box.MaxLng)
+
+	// Scanning occupied cells is cheaper than probing a mostly empty range.
+	if span := (hi.x - lo.x + 1) * (hi.y - lo.y + 1); span > len(ix.cells) {
+		for c, bucket := range ix.cells {
+			if c.x >= lo.x && c.x <= hi.x && c.y >= lo.y && c.y <= hi.y {
+				collect(bucket)
+			}
+		}
+		return locations
+	}
+
+	for x := lo.x; x <= hi.x; x++ {
+		for y := lo.y; y <= hi.y; y++ {
+			if bucket, ok := ix.cells[cell{x: x, y: y}]; ok {
+				collect(bucket)
+			}
+		}
+	}
+	return locations
+}
+
+func (ix *Index) withinRadius(lat, lng, radiusKm float64, color string) []model.NearbyLocation {
+	candidates := ix.inBox(geo.BoundingBoxAround(lat, lng, radiusKm))
+
+	nearby := make([]model.NearbyLocation, 0, len(candidates))
+	for _, loc := range candidates {
+		if color != "" && !strings.EqualFold(loc.Color, color) {
+			continue
+		}
+		if d := geo.Haversine(lat, lng, loc.Latitude, loc.Longitude); d <= radiusKm {
+			nearby = append(nearby, model.NearbyLocation{Location: loc, Distance: d})
+		}
+	}
+
+	sort.Slice(nearby, func(i, j int) bool {
+		if nearby[i].Distance != nearby[j].Distance {
+			return nearby[i].Distance < nearby[j].Distance
+		}
+		return nearby[i].ID < nearby[j].ID
+	})
+	return nearby
+}
+
+func sortByID(locations []model.Location) {
+	sort.Slice(locations, func(i, j int) bool {
+		return locations[i].ID < locations[j].ID
+	})
+}
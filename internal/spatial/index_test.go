@@ -0,0 +1,204 @@
+package spatial
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yusufbulac/location-routing-service/internal/geo"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+)
+
+var colors = []string{"#ff0000", "#00ff00", "#0000ff"}
+
+func syntheticLocations(n int, seed int64) []model.Location {
+	rng := rand.New(rand.NewSource(seed))
+	locations := make([]model.Location, n)
+	for i := range locations {
+		locations[i] = model.Location{
+			ID:        uint(i + 1),
+			Latitude:  rng.Float64()*170 - 85,
+			Longitude: rng.Float64()*360 - 180,
+			Color:     colors[rng.Intn(len(colors))],
+		}
+	}
+	return locations
+}
+
+// linearWithinRadius is the full-scan equivalent of Index.WithinRadius.
+func linearWithinRadius(locations []model.Location, lat, lng, radiusKm float64, color string) []model.NearbyLocation {
+	var nearby []model.NearbyLocation
+	for _, loc := range locations {
+		if color != "" && !strings.EqualFold(loc.Color, color) {
+			continue
+		}
+		if d := geo.Haversine(lat, lng, loc.Latitude, loc.Longitude); d <= radiusKm {
+			nearby = append(nearby, model.NearbyLocation{Location: loc, Distance: d})
+		}
+	}
+	sort.Slice(nearby, func(i, j int) bool {
+		if nearby[i].Distance != nearby[j].Distance {
+			return nearby[i].Distance < nearby[j].Distance
+		}
+		return nearby[i].ID < nearby[j].ID
+	})
+	return nearby
+}
+
+func linearInBox(locations []model.Location, box geo.BoundingBox) []model.Location {
+	var inside []model.Location
+	for _, loc := range locations {
+		if box.Contains(loc.Latitude, loc.Longitude) {
+			inside = append(inside, loc)
+		}
+	}
+	return inside
+}
+
+func locationIDs(locations []model.Location) []uint {
+	out := make([]uint, len(locations))
+	for i, loc := range locations {
+		out[i] = loc.ID
+	}
+	return out
+}
+
+func nearbyIDs(nearby []model.NearbyLocation) []uint {
+	out := make([]uint, len(nearby))
+	for i, loc := range nearby {
+		out[i] = loc.ID
+	}
+	return out
+}
+
+func TestIndex_WithinRadiusMatchesLinearScan(t *testing.T) {
+	locations := syntheticLocations(5000, 1)
+	ix := NewIndex(DefaultCellSize)
+	ix.Load(locations)
+
+	queries := []struct{ lat, lng, radius float64 }{
+		{41, 29, 500},
+		{0, 179.9, 800},
+		{84, -30, 1500},
+		{-10, -60, 50},
+	}
+	for _, q := range queries {
+		expected := linearWithinRadius(locations, q.lat, q.lng, q.radius, "")
+		actual := ix.WithinRadius(q.lat, q.lng, q.radius, len(locations), 0)
+		assert.Equal(t, nearbyIDs(expected), nearbyIDs(actual), "query %+v", q)
+	}
+}
+
+func TestIndex_InBoxMatchesLinearScan(t *testing.T) {
+	locations := syntheticLocations(5000, 2)
+	ix := NewIndex(DefaultCellSize)
+	ix.Load(locations)
+
+	boxes := []geo.BoundingBox{
+		{MinLat: 30, MinLng: 20, MaxLat: 50, MaxLng: 40},
+		{MinLat: -30, MinLng: 170, MaxLat: 10, MaxLng: -165},
+		{MinLat: -90, MinLng: -180, MaxLat: 90, MaxLng: 180},
+	}
+	for _, box := range boxes {
+		expected := linearInBox(locations, box)
+		actual := ix.InBox(box, len(locations), 0)
+		assert.Equal(t, locationIDs(expected), locationIDs(actual), "box %+v", box)
+	}
+
+	page := ix.InBox(boxes[2], 10, 20)
+	assert.Equal(t, locationIDs(locations[20:30]), locationIDs(page))
+}
+
+func TestIndex_NearestMatchesLinearScan(t *testing.T) {
+	locations := syntheticLocations(5000, 3)
+	ix := NewIndex(DefaultCellSize)
+	ix.Load(locations)
+
+	for _, color := range []string{"", "#00FF00"} {
+		expected := linearWithinRadius(locations, 12, 34, 1e6, color)[:7]
+		actual := ix.Nearest(12, 34, 7, color)
+		assert.Equal(t, nearbyIDs(expected), nearbyIDs(actual), "color %q", color)
+	}
+
+	all := ix.Nearest(0, 0, len(locations)+10, "")
+	assert.Len(t, all, len(locations))
+}
+
+func TestIndex_UpsertAndRemove(t *testing.T) {
+	ix := NewIndex(DefaultCellSize)
+	ix.Load([]model.Location{
+		{ID: 1, Latitude: 41, Longitude: 29},
+		{ID: 2, Latitude: 40, Longitude: -74},
+	})
+
+	ix.Upsert(model.Location{ID: 1, Latitude: 41.5, Longitude: 29.5})
+	ix.Upsert(model.Location{ID: 3, Latitude: 41.4, Longitude: 29.4})
+	ix.Remove(2)
+	ix.Remove(42)
+
+	assert.Equal(t, 2, ix.Len())
+	assert.Equal(t, []uint{1, 3}, locationIDs(ix.All()))
+
+	near := ix.WithinRadius(41.5, 29.5, 1, 10, 0)
+	assert.Equal(t, []uint{1}, nearbyIDs(near))
+	assert.Empty(t, ix.WithinRadius(41, 29, 1, 10, 0))
+}
+
+const benchmarkPoints = 100_000
+
+func benchmarkSetup(b *testing.B) ([]model.Location, *Index) {
+	b.Helper()
+	locations := syntheticLocations(benchmarkPoints, 42)
+	ix := NewIndex(DefaultCellSize)
+	ix.Load(locations)
+	b.ResetTimer()
+	return locations, ix
+}
+
+func BenchmarkWithinRadius_Index(b *testing.B) {
+	_, ix := benchmarkSetup(b)
+	for i := 0; i < b.N; i++ {
+		ix.WithinRadius(41, 29, 200, 50, 0)
+	}
+}
+
+func BenchmarkWithinRadius_LinearScan(b *testing.B) {
+	locations, _ := benchmarkSetup(b)
+	for i := 0; i < b.N; i++ {
+		linearWithinRadius(locations, 41, 29, 200, "")
+	}
+}
+
+func BenchmarkNearest_Index(b *testing.B) {
+	_, ix := benchmarkSetup(b)
+	for i := 0; i < b.N; i++ {
+		ix.Nearest(41, 29, 5, "")
+	}
+}
+
+func BenchmarkNearest_LinearScan(b *testing.B) {
+	locations, _ := benchmarkSetup(b)
+	for i := 0; i < b.N; i++ {
+		linearWithinRadius(locations, 41, 29, 1e6, "")
+	}
+}
+
+func BenchmarkInBox_Index(b *testing.B) {
+	_, ix := benchmarkSetup(b)
+	box := geo.BoundingBox{MinLat: 40, MinLng: 28, MaxLat: 42, MaxLng: 30}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ix.InBox(box, 50, 0)
+	}
+}
+
+func BenchmarkInBox_LinearScan(b *testing.B) {
+	locations, _ := benchmarkSetup(b)
+	box := geo.BoundingBox{MinLat: 40, MinLng: 28, MaxLat: 42, MaxLng: 30}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		linearInBox(locations, box)
+	}
+}
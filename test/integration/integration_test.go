@@ -10,7 +10,9 @@ func TestMain(m *testing.M) {
 	testutils.SetupTestDB()
 	testutils.CleanDatabase()
 	testutils.SeedLocations()
+	testutils.SetupTestServer()
 
 	code := m.Run()
+	testutils.Teardown()
 	os.Exit(code)
 }
@@ -1,6 +1,7 @@
 package integration
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -10,7 +11,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/yusufbulac/location-routing-service/internal/cache"
+	"github.com/yusufbulac/location-routing-service/internal/dto"
 	"github.com/yusufbulac/location-routing-service/internal/model"
+	"github.com/yusufbulac/location-routing-service/internal/repository"
+	"github.com/yusufbulac/location-routing-service/internal/service"
 	"github.com/yusufbulac/location-routing-service/test/integration/testutils"
 )
 
@@ -61,7 +66,7 @@ func TestGetAllLocations(t *testing.T) {
 
 func TestUpdateLocation(t *testing.T) {
 	initial := model.Location{Name: "Initial", Latitude: 41, Longitude: 29, Color: "#123456"}
-	if err := testutils.TestDB.Create(&initial).Error; err != nil {
+	if err := testutils.Locations.Create(context.Background(), &initial); err != nil {
 		t.Fatalf("Failed to create location: %v", err)
 	}
 
@@ -135,3 +140,496 @@ func TestGetAllLocationsPagination(t *testing.T) {
 	require.NoError(t, err, "Failed to decode paginated locations list JSON")
 	assert.LessOrEqual(t, len(locations), 2, "Returned more than limit")
 }
+
+func TestDeleteAndRestoreLocation(t *testing.T) {
+	loc := model.Location{Name: "Disposable", Latitude: 41, Longitude: 29, Color: "#111111"}
+	require.NoError(t, testutils.Locations.Create(context.Background(), &loc))
+	url := "/api/v1/locations/" + strconv.Itoa(int(loc.ID))
+
+	resp := testutils.Delete(t, url)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = testutils.Get(t, url)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Deleted location must not be returned")
+
+	resp = testutils.Delete(t, url)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "Deleting twice must report not found")
+
+	restorePath := "/api/v1/admin/locations/" + strconv.Itoa(int(loc.ID)) + "/restore"
+	resp = testutils.Post(t, restorePath, nil)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "Restoring requires the admin token")
+
+	resp = testutils.Admin(t, http.MethodPost, restorePath)
+	defer resp.Body.Close()
+	body := readAndLogBody(t, resp)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var restored model.Location
+	require.NoError(t, json.Unmarshal(body, &restored), "Failed to decode restored location JSON")
+	assert.Equal(t, loc.ID, restored.ID)
+}
+
+func TestPurgeDeletedLocations(t *testing.T) {
+	resp := testutils.Delete(t, "/api/v1/admin/locations/deleted")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "Purging requires the admin token")
+
+	resp = testutils.Admin(t, http.MethodDelete, "/api/v1/admin/locations/deleted")
+	body := readAndLogBody(t, resp)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var purged dto.PurgeResponse
+	require.NoError(t, json.Unmarshal(body, &purged))
+	assert.Equal(t, "720h0m0s", purged.OlderThan, "The retention is the default age")
+}
+
+func TestPurgeDeletedLocations_RejectsAgeBelowRetention(t *testing.T) {
+	for _, olderThan := range []string{"0s", "-1h", "1h", "719h"} {
+		resp := testutils.Admin(t, http.MethodDelete, "/api/v1/admin/locations/deleted?older_than="+olderThan)
+		body := readAndLogBody(t, resp)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, olderThan)
+
+		var errResp dto.ErrorResponse
+		require.NoError(t, json.Unmarshal(body, &errResp))
+		assert.Equal(t, "older_than below the retention", errResp.Message, olderThan)
+	}
+}
+
+func TestGetNearbyLocations(t *testing.T) {
+	// Point A (New York) is seeded; Point C (Chicago) is ~1150 km away.
+	resp := testutils.Get(t, "/api/v1/locations/nearby?lat=40.7128&lng=-74.0060&radius=50")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := readAndLogBody(t, resp)
+
+	var nearby []model.NearbyLocation
+	err := json.Unmarshal(body, &nearby)
+	require.NoError(t, err, "Failed to decode nearby locations JSON")
+	require.NotEmpty(t, nearby)
+	assert.Equal(t, "Point A", nearby[0].Name)
+	for i, loc := range nearby {
+		assert.LessOrEqual(t, loc.Distance, 50.0)
+		if i > 0 {
+			assert.GreaterOrEqual(t, loc.Distance, nearby[i-1].Distance, "Results must be ordered by distance")
+		}
+	}
+}
+
+func TestGetNearbyLocations_InvalidRadius(t *testing.T) {
+	for _, radius := range []string{"-1", "0", "500.5", "20000"} {
+		resp := testutils.Get(t, "/api/v1/locations/nearby?lat=40.7&lng=-74&radius="+radius)
+		_ = readAndLogBody(t, resp)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, radius)
+	}
+}
+
+func TestGetNearestLocations(t *testing.T) {
+	resp := testutils.Get(t, "/api/v1/locations/nearest?lat=41.8&lng=-87.6&k=2&color=%230000ff")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := readAndLogBody(t, resp)
+
+	var nearest []model.NearbyLocation
+	err := json.Unmarshal(body, &nearest)
+	require.NoError(t, err, "Failed to decode nearest locations JSON")
+	require.NotEmpty(t, nearest)
+	assert.LessOrEqual(t, len(nearest), 2)
+	assert.Equal(t, "Point C", nearest[0].Name)
+	for _, loc := range nearest {
+		assert.Equal(t, "#0000ff", loc.Color)
+	}
+}
+
+func TestGetAllLocations_BoundingBox(t *testing.T) {
+	fiji := model.Location{Name: "Fiji", Latitude: -17.7, Longitude: 178.0, Color: "#00aaff"}
+	samoa := model.Location{Name: "Samoa", Latitude: -13.8, Longitude: -172.1, Color: "#00aaff"}
+	require.NoError(t, testutils.Locations.Create(context.Background(), &fiji))
+	require.NoError(t, testutils.Locations.Create(context.Background(), &samoa))
+
+	resp := testutils.Get(t, "/api/v1/locations?bbox=170,-20,-170,-10&limit=10")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := readAndLogBody(t, resp)
+
+	var locations []model.Location
+	err := json.Unmarshal(body, &locations)
+	require.NoError(t, err, "Failed to decode bbox locations JSON")
+
+	names := make([]string, 0, len(locations))
+	for _, loc := range locations {
+		names = append(names, loc.Name)
+	}
+	assert.ElementsMatch(t, []string{"Fiji", "Samoa"}, names)
+}
+
+func TestGetAllLocations_InvalidBoundingBox(t *testing.T) {
+	resp := testutils.Get(t, "/api/v1/locations?bbox=1,2,3")
+	defer resp.Body.Close()
+
+	_ = readAndLogBody(t, resp)
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestCreateLocationsBatch(t *testing.T) {
+	reqBody := map[string]interface{}{
+		"items": []map[string]interface{}{
+			{"name": "Batch A", "latitude": 40.1, "longitude": 29.1, "color": "#aaaaaa"},
+			{"name": "Batch B", "latitude": 40.2, "longitude": 29.2, "color": "#bbbbbb"},
+		},
+	}
+	resp := testutils.Post(t, "/api/v1/locations/batch", reqBody)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	body := readAndLogBody(t, resp)
+
+	var result dto.BatchLocationResponse
+	require.NoError(t, json.Unmarshal(body, &result), "Failed to decode batch response JSON")
+	assert.Equal(t, dto.BatchModeAtomic, result.Mode)
+	require.Len(t, result.Created, 2)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, 1, result.Created[1].Index)
+	assert.NotZero(t, result.Created[1].Location.ID)
+	assert.Equal(t, "Batch B", result.Created[1].Location.Name)
+}
+
+func TestCreateLocationsBatch_AtomicRejectsInvalidItems(t *testing.T) {
+	reqBody := map[string]interface{}{
+		"mode": "atomic",
+		"items": []map[string]interface{}{
+			{"name": "Atomic Valid", "latitude": 40.1, "longitude": 29.1, "color": "#aaaaaa"},
+			{"name": "Atomic Invalid", "latitude": 95, "longitude": 29.2, "color": "#bbbbbb"},
+		},
+	}
+	resp := testutils.Post(t, "/api/v1/locations/batch", reqBody)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	body := readAndLogBody(t, resp)
+
+	var result dto.BatchLocationResponse
+	require.NoError(t, json.Unmarshal(body, &result), "Failed to decode batch response JSON")
+	assert.Empty(t, result.Created)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, 1, result.Errors[0].Index)
+
+	var stored int64
+	require.NoError(t, testutils.TestDB.Model(&model.Location{}).Where("name = ?", "Atomic Valid").Count(&stored).Error)
+	assert.Zero(t, stored, "Atomic mode must not store valid items of an invalid batch")
+}
+
+func TestCreateLocationsBatch_BestEffortStoresValidItems(t *testing.T) {
+	reqBody := map[string]interface{}{
+		"mode": "best_effort",
+		"items": []map[string]interface{}{
+			{"name": "", "latitude": 40.1, "longitude": 29.1, "color": "#aaaaaa"},
+			{"name": "Best Effort", "latitude": 40.2, "longitude": 29.2, "color": "#bbbbbb"},
+			{"name": "Bad Color", "latitude": 40.3, "longitude": 29.3, "color": "blue"},
+		},
+	}
+	resp := testutils.Post(t, "/api/v1/locations/batch", reqBody)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	body := readAndLogBody(t, resp)
+
+	var result dto.BatchLocationResponse
+	require.NoError(t, json.Unmarshal(body, &result), "Failed to decode batch response JSON")
+	require.Len(t, result.Created, 1)
+	assert.Equal(t, 1, result.Created[0].Index)
+	require.Len(t, result.Errors, 2)
+	assert.Equal(t, 0, result.Errors[0].Index)
+	assert.Equal(t, 2, result.Errors[1].Index)
+}
+
+func TestCreateLocationsBatch_InvalidMode(t *testing.T) {
+	reqBody := map[string]interface{}{
+		"mode":  "sometimes",
+		"items": []map[string]interface{}{{"name": "X", "latitude": 1, "longitude": 1, "color": "#ffffff"}},
+	}
+	resp := testutils.Post(t, "/api/v1/locations/batch", reqBody)
+	defer resp.Body.Close()
+
+	_ = readAndLogBody(t, resp)
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestGetRoute_ClosedTourFromStartLocation(t *testing.T) {
+	var depot model.Location
+	require.NoError(t, testutils.TestDB.Where("name = ?", "Point A").First(&depot).Error)
+
+	resp := testutils.Get(t, "/api/v1/route?return_to_start=true&start_id="+strconv.Itoa(int(depot.ID)))
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := readAndLogBody(t, resp)
+
+	var route dto.RouteResponse
+	require.NoError(t, json.Unmarshal(body, &route), "Failed to decode route JSON")
+	require.NotNil(t, route.Start)
+	assert.Equal(t, depot.ID, route.Start.ID)
+	require.NotEmpty(t, route.Stops)
+	assert.Equal(t, depot.ID, route.Stops[len(route.Stops)-1].Location.ID, "The tour ends back at the start location")
+	for _, stop := range route.Stops[:len(route.Stops)-1] {
+		assert.NotEqual(t, depot.ID, stop.Location.ID, "The start location is only visited at the end")
+	}
+}
+
+func TestGetRoute_FixedEnd(t *testing.T) {
+	var end model.Location
+	require.NoError(t, testutils.TestDB.Where("name = ?", "Point B").First(&end).Error)
+
+	resp := testutils.Get(t, "/api/v1/route?lat=40.7&lng=-74&end_id="+strconv.Itoa(int(end.ID)))
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := readAndLogBody(t, resp)
+
+	var route dto.RouteResponse
+	require.NoError(t, json.Unmarshal(body, &route), "Failed to decode route JSON")
+	assert.Nil(t, route.Start)
+	require.NotEmpty(t, route.Stops)
+	assert.Equal(t, end.ID, route.Stops[len(route.Stops)-1].Location.ID)
+}
+
+func TestGetRoute_UnknownStartLocation(t *testing.T) {
+	resp := testutils.Get(t, "/api/v1/route?start_id=999999")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestGetRoute_InvalidAnchors(t *testing.T) {
+	for _, query := range []string{
+		"lat=40.7&lng=-74&return_to_start=true",
+		"lat=40.7&lng=-74&start_id=1",
+		"start_id=abc",
+		"start_id=1&end_id=2&return_to_start=true",
+	} {
+		resp := testutils.Get(t, "/api/v1/route?"+query)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+}
+
+func TestBuildRoute_SelectedLocations(t *testing.T) {
+	var a, c model.Location
+	require.NoError(t, testutils.TestDB.Where("name = ?", "Point A").First(&a).Error)
+	require.NoError(t, testutils.TestDB.Where("name = ?", "Point C").First(&c).Error)
+
+	resp := testutils.Post(t, "/api/v1/routes", map[string]interface{}{
+		"location_ids": []uint{c.ID, a.ID},
+		"start":        map[string]float64{"latitude": 40.7, "longitude": -74},
+	})
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := readAndLogBody(t, resp)
+
+	var route dto.RouteResponse
+	require.NoError(t, json.Unmarshal(body, &route), "Failed to decode route JSON")
+	require.Len(t, route.Stops, 2, "Only the selected locations are visited")
+	assert.Equal(t, a.ID, route.Stops[0].Location.ID)
+	assert.Equal(t, c.ID, route.Stops[1].Location.ID)
+}
+
+func TestBuildRoute_ColorFromDepot(t *testing.T) {
+	var depot model.Location
+	require.NoError(t, testutils.TestDB.Where("name = ?", "Point B").First(&depot).Error)
+
+	resp := testutils.Post(t, "/api/v1/routes", map[string]interface{}{
+		"colors":          []string{"#FF0000"},
+		"start_id":        depot.ID,
+		"return_to_start": true,
+	})
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := readAndLogBody(t, resp)
+
+	var route dto.RouteResponse
+	require.NoError(t, json.Unmarshal(body, &route), "Failed to decode route JSON")
+	require.NotNil(t, route.Start)
+	assert.Equal(t, depot.ID, route.Start.ID, "The depot need not match the color filter")
+	require.NotEmpty(t, route.Stops)
+	for _, stop := range route.Stops[:len(route.Stops)-1] {
+		assert.Equal(t, "#ff0000", stop.Location.Color)
+	}
+	assert.Equal(t, depot.ID, route.Stops[len(route.Stops)-1].Location.ID)
+}
+
+func TestBuildRoute_MissingLocation(t *testing.T) {
+	resp := testutils.Post(t, "/api/v1/routes", map[string]interface{}{
+		"location_ids": []uint{999999},
+		"start":        map[string]float64{"latitude": 40.7, "longitude": -74},
+	})
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestBuildRoute_InvalidRequests(t *testing.T) {
+	start := map[string]float64{"latitude": 40.7, "longitude": -74}
+	for name, reqBody := range map[string]map[string]interface{}{
+		"no selection":      {"start": start},
+		"no start":          {"location_ids": []uint{1}},
+		"start and id":      {"location_ids": []uint{1}, "start": start, "start_id": 1},
+		"invalid color":     {"colors": []string{"red"}, "start": start},
+		"closed from point": {"colors": []string{"#ff0000"}, "start": start, "return_to_start": true},
+	} {
+		resp := testutils.Post(t, "/api/v1/routes", reqBody)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+	}
+}
+
+func TestDistanceMatrix(t *testing.T) {
+	var a, c model.Location
+	require.NoError(t, testutils.TestDB.Where("name = ?", "Point A").First(&a).Error)
+	require.NoError(t, testutils.TestDB.Where("name = ?", "Point C").First(&c).Error)
+
+	resp := testutils.Post(t, "/api/v1/matrix?unit=mi", map[string]interface{}{
+		"origins":      []map[string]interface{}{{"id": a.ID}, {"latitude": 40.7, "longitude": -74}},
+		"destinations": []map[string]interface{}{{"id": a.ID}, {"id": c.ID}, {"latitude": 0, "longitude": 0}},
+		"speed_kmh":    80,
+	})
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body := readAndLogBody(t, resp)
+
+	var matrix dto.MatrixResponse
+	require.NoError(t, json.Unmarshal(body, &matrix), "Failed to decode matrix JSON")
+	assert.Equal(t, dto.UnitMiles, matrix.Unit)
+	assert.Equal(t, float64(80), matrix.SpeedKmh)
+	assert.Equal(t, c.Latitude, matrix.Destinations[1].Latitude, "Location IDs resolve to their coordinates")
+	require.Len(t, matrix.Distances, 2)
+	require.Len(t, matrix.Distances[0], 3)
+	assert.Zero(t, matrix.Distances[0][0])
+	assert.Greater(t, matrix.Distances[1][2], matrix.Distances[1][1])
+	assert.InDelta(t, matrix.Distances[0][1]*1.609344/80*3600, matrix.Durations[0][1], 1e-6)
+}
+
+func TestDistanceMatrix_SquareOverOrigins(t *testing.T) {
+	resp := testutils.Post(t, "/api/v1/matrix", map[string]interface{}{
+		"origins": []map[string]interface{}{{"latitude": 41.0082, "longitude": 28.9784}, {"latitude": 39.9334, "longitude": 32.8597}},
+	})
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var matrix dto.MatrixResponse
+	require.NoError(t, json.Unmarshal(readAndLogBody(t, resp), &matrix))
+	require.Len(t, matrix.Distances, 2)
+	assert.Equal(t, matrix.Distances[0][1], matrix.Distances[1][0])
+	assert.Len(t, matrix.Destinations, 2)
+}
+
+func TestDistanceMatrix_MissingLocation(t *testing.T) {
+	resp := testutils.Post(t, "/api/v1/matrix", map[string]interface{}{
+		"origins": []map[string]interface{}{{"id": 999999}},
+	})
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestDistanceMatrix_InvalidRequests(t *testing.T) {
+	point := map[string]interface{}{"latitude": 40.7, "longitude": -74}
+	tooMany := make([]map[string]interface{}, 1001)
+	for i := range tooMany {
+		tooMany[i] = point
+	}
+	for name, reqBody := range map[string]map[string]interface{}{
+		"no origins":         {"destinations": []map[string]interface{}{point}},
+		"half a coordinate":  {"origins": []map[string]interface{}{{"latitude": 40.7}}},
+		"id and coordinates": {"origins": []map[string]interface{}{{"id": 1, "latitude": 40.7, "longitude": -74}}},
+		"latitude range":     {"origins": []map[string]interface{}{{"latitude": 91, "longitude": 0}}},
+		"negative speed":     {"origins": []map[string]interface{}{point}, "speed_kmh": -5},
+		"too many origins":   {"origins": tooMany, "destinations": []map[string]interface{}{point}},
+	} {
+		resp := testutils.Post(t, "/api/v1/matrix", reqBody)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+	}
+}
+
+func TestGetRoute_Units(t *testing.T) {
+	totals := make(map[string]float64)
+	for _, unit := range []string{"km", "mi", "nmi"} {
+		resp := testutils.Get(t, "/api/v1/route?lat=40.7&lng=-74&unit="+unit)
+		body := readAndLogBody(t, resp)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, unit)
+
+		var route dto.RouteResponse
+		require.NoError(t, json.Unmarshal(body, &route), "Failed to decode route JSON")
+		assert.Equal(t, dto.DistanceUnit(unit), route.Summary.Unit)
+		require.NotEmpty(t, route.Stops)
+		last := route.Stops[len(route.Stops)-1]
+		assert.InDelta(t, route.Summary.TotalDistance, last.CumulativeDistance, 1e-9, unit)
+		totals[unit] = route.Summary.TotalDistance
+	}
+	assert.InDelta(t, totals["km"]/1.609344, totals["mi"], 1e-6)
+	assert.InDelta(t, totals["km"]/1.852, totals["nmi"], 1e-6)
+}
+
+func TestGetRoute_InvalidUnit(t *testing.T) {
+	resp := testutils.Get(t, "/api/v1/route?lat=40.7&lng=-74&unit=furlong")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var errResp dto.ErrorResponse
+	require.NoError(t, json.Unmarshal(readAndLogBody(t, resp), &errResp))
+	assert.Equal(t, "Invalid unit", errResp.Message)
+	assert.Contains(t, errResp.Details, "furlong")
+}
+
+func routeStopNames(t *testing.T, path string) []string {
+	resp := testutils.Get(t, path)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var route dto.RouteResponse
+	require.NoError(t, json.Unmarshal(readAndLogBody(t, resp), &route), "Failed to decode route JSON")
+	names := make([]string, len(route.Stops))
+	for i, stop := range route.Stops {
+		names[i] = stop.Location.Name
+	}
+	return names
+}
+
+func TestGetRoute_SeesWritesByAnotherReplica(t *testing.T) {
+	const path = "/api/v1/route?lat=41&lng=29"
+	require.NotContains(t, routeStopNames(t, path), "Other Replica")
+
+	// a second instance with its own route cache, like another replica or a CLI command
+	other := service.NewLocationService(repository.NewLocationRepository(testutils.TestDB), service.WithCache(cache.NewMemoryCache(16)))
+	require.NoError(t, other.CreateLocation(context.Background(), &model.Location{Name: "Other Replica", Latitude: 41.01, Longitude: 29.01, Color: "#abcdef"}))
+
+	assert.Contains(t, routeStopNames(t, path), "Other Replica", "The cached route is not served after the write")
+}
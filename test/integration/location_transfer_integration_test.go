@@ -0,0 +1,358 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yusufbulac/location-routing-service/internal/dto"
+	"github.com/yusufbulac/location-routing-service/internal/format"
+	"github.com/yusufbulac/location-routing-service/internal/handler"
+	"github.com/yusufbulac/location-routing-service/internal/middleware"
+	"github.com/yusufbulac/location-routing-service/internal/model"
+	"github.com/yusufbulac/location-routing-service/internal/repository"
+	"github.com/yusufbulac/location-routing-service/internal/service"
+	"github.com/yusufbulac/location-routing-service/test/integration/testutils"
+)
+
+func decodeImport(t *testing.T, resp *http.Response) dto.ImportResponse {
+	body := readAndLogBody(t, resp)
+	var result dto.ImportResponse
+	require.NoError(t, json.Unmarshal(body, &result), "Failed to decode import response JSON")
+	return result
+}
+
+func countByName(t *testing.T, name string) int64 {
+	var count int64
+	require.NoError(t, testutils.TestDB.Model(&model.Location{}).Where("name = ?", name).Count(&count).Error)
+	return count
+}
+
+func TestImportLocationsCSV(t *testing.T) {
+	csvBody := "Store,Y,X,Color\nCSV Depot,40.5,29.5,#123456\nCSV Shop,40.6,29.6,#654321\n"
+	resp := testutils.PostRaw(t, "/api/v1/locations/import?columns[name]=Store&columns[latitude]=Y&columns[longitude]=X", "text/csv", strings.NewReader(csvBody))
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	result := decodeImport(t, resp)
+	assert.Equal(t, 2, result.Rows)
+	assert.Equal(t, 2, result.Created)
+	assert.Equal(t, int64(1), countByName(t, "CSV Shop"))
+}
+
+func TestImportLocationsCSV_AtomicRejectsInvalidRows(t *testing.T) {
+	csvBody := "name,lat,lng,color\nCSV Atomic,40.5,29.5,#123456\nCSV Broken,north,29.6,#654321\n"
+	resp := testutils.PostRaw(t, "/api/v1/locations/import", "text/csv", strings.NewReader(csvBody))
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	result := decodeImport(t, resp)
+	assert.Equal(t, 1, result.Valid)
+	assert.Zero(t, result.Created)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, 3, result.Errors[0].Row)
+	assert.Zero(t, countByName(t, "CSV Atomic"))
+}
+
+func TestImportLocationsCSV_DryRun(t *testing.T) {
+	csvBody := "name,lat,lng,color\nCSV Dry,40.5,29.5,#123456\nCSV Dry Invalid,40.5,29.5,red\n"
+	resp := testutils.PostRaw(t, "/api/v1/locations/import?mode=best_effort&dry_run=true", "text/csv", strings.NewReader(csvBody))
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	result := decodeImport(t, resp)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, 1, result.Valid)
+	assert.Equal(t, 1, result.Invalid)
+	assert.Zero(t, countByName(t, "CSV Dry"))
+}
+
+func TestImportLocationsCSV_MultipartBestEffort(t *testing.T) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", "points.csv")
+	require.NoError(t, err)
+	_, err = file.Write([]byte("name,latitude,longitude,color\nCSV Upload,40.5,29.5,#123456\n,40.5,29.5,#123456\n"))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	resp := testutils.PostRaw(t, "/api/v1/locations/import?mode=best_effort", form.FormDataContentType(), &body)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	result := decodeImport(t, resp)
+	assert.Equal(t, 1, result.Created)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, 3, result.Errors[0].Row)
+	assert.Equal(t, int64(1), countByName(t, "CSV Upload"))
+}
+
+func TestImportLocationsCSV_MissingColumns(t *testing.T) {
+	resp := testutils.PostRaw(t, "/api/v1/locations/import", "text/csv", strings.NewReader("name,color\nA,#ffffff\n"))
+	defer resp.Body.Close()
+
+	_ = readAndLogBody(t, resp)
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestExportLocationsCSV(t *testing.T) {
+	resp := testutils.Get(t, "/api/v1/locations/export.csv")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	require.NoError(t, err)
+	require.NotEmpty(t, records)
+	assert.Equal(t, []string{"id", "name", "latitude", "longitude", "color", "created_at", "updated_at"}, records[0])
+
+	var live int64
+	require.NoError(t, testutils.TestDB.Model(&model.Location{}).Count(&live).Error)
+	assert.Len(t, records, int(live)+1, "Every live location is exported")
+	assert.Equal(t, "complete", resp.Trailer.Get("X-Export-Status"))
+}
+
+// failingStreamRepository fails a streamed read after its first batch.
+type failingStreamRepository struct {
+	repository.LocationRepository
+}
+
+func (r failingStreamRepository) FindInBatches(ctx context.Context, size int, fn func([]model.Location) error) error {
+	return r.LocationRepository.FindInBatches(ctx, size, func(batch []model.Location) error {
+		if err := fn(batch); err != nil {
+			return err
+		}
+		return errors.New("connection reset")
+	})
+}
+
+func TestImportLocations_RejectsLargeBodies(t *testing.T) {
+	locationHandler := handler.NewLocationHandler(service.NewLocationService(repository.NewLocationRepository(testutils.TestDB)))
+	router := gin.New()
+	router.POST("/import", middleware.MaxBodySize(1024), locationHandler.ImportLocations)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	rows := "name,latitude,longitude,color\n" + strings.Repeat("Oversized,40.5,29.5,#123456\n", 100)
+	features := `{"type": "FeatureCollection", "features": [` + strings.Repeat(`{"type": "Feature", "geometry": {"type": "Point", "coordinates": [29.5, 40.5]}, "properties": {"name": "Oversized", "color": "#123456"}},`, 50)
+	var upload bytes.Buffer
+	form := multipart.NewWriter(&upload)
+	file, err := form.CreateFormFile("file", "points.csv")
+	require.NoError(t, err)
+	_, err = file.Write([]byte(rows))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	for name, body := range map[string]struct {
+		contentType string
+		data        string
+	}{
+		"csv":       {"text/csv", rows},
+		"geojson":   {"application/geo+json", features},
+		"multipart": {form.FormDataContentType(), upload.String()},
+	} {
+		resp, err := http.Post(srv.URL+"/import", body.contentType, strings.NewReader(body.data))
+		require.NoError(t, err, name)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode, name)
+		var errResp dto.ErrorResponse
+		require.NoError(t, json.Unmarshal(readAndLogBody(t, resp), &errResp), name)
+		resp.Body.Close()
+		assert.Equal(t, "at most 1024 bytes per import", errResp.Details, name)
+	}
+	assert.Zero(t, countByName(t, "Oversized"))
+
+	resp, err := http.Post(srv.URL+"/import", "text/csv", strings.NewReader("name,latitude,longitude,color\nSmall Import,40.5,29.5,#123456\n"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, "bodies within the limit are imported")
+}
+
+func TestExportLocationsCSV_ReportsTruncation(t *testing.T) {
+	repo := failingStreamRepository{LocationRepository: repository.NewLocationRepository(testutils.TestDB)}
+	locationHandler := handler.NewLocationHandler(service.NewLocationService(repo))
+	router := gin.New()
+	router.GET("/export.csv", locationHandler.ExportLocationsCSV)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/export.csv")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "the failure happens after the first batch is sent")
+	_, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "truncated", resp.Trailer.Get("X-Export-Status"))
+}
+
+func TestImportLocationsGeoJSON(t *testing.T) {
+	geoJSON := `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [29.5, 40.5]}, "properties": {"name": "GeoJSON Depot", "color": "#123456"}},
+		{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": []}, "properties": {"name": "Area", "color": "#123456"}}
+	]}`
+	resp := testutils.PostRaw(t, "/api/v1/locations/import?mode=best_effort", "application/geo+json", strings.NewReader(geoJSON))
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	result := decodeImport(t, resp)
+	assert.Equal(t, 1, result.Created)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, 1, result.Errors[0].Index)
+	assert.Zero(t, result.Errors[0].Row, "GeoJSON records have no row")
+	assert.Equal(t, int64(1), countByName(t, "GeoJSON Depot"))
+}
+
+func TestGetAllLocations_GeoJSON(t *testing.T) {
+	resp := testutils.GetAccept(t, "/api/v1/locations?limit=2", "application/geo+json")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/geo+json", resp.Header.Get("Content-Type"))
+
+	body := readAndLogBody(t, resp)
+
+	var fc format.FeatureCollection
+	require.NoError(t, json.Unmarshal(body, &fc), "Failed to decode FeatureCollection")
+	assert.Equal(t, "FeatureCollection", fc.Type)
+	require.Len(t, fc.Features, 2)
+	assert.Equal(t, "Point", fc.Features[0].Geometry.Type)
+	assert.Contains(t, fc.Features[0].Properties, "name")
+	assert.Contains(t, fc.Features[0].Properties, "color")
+}
+
+func TestGetRoute_GeoJSON(t *testing.T) {
+	resp := testutils.GetAccept(t, "/api/v1/route?lat=40.7&lng=-74", "application/geo+json")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/geo+json", resp.Header.Get("Content-Type"))
+
+	body := readAndLogBody(t, resp)
+
+	var fc format.FeatureCollection
+	require.NoError(t, json.Unmarshal(body, &fc), "Failed to decode FeatureCollection")
+	require.NotEmpty(t, fc.Features)
+	assert.Equal(t, "LineString", fc.Features[0].Geometry.Type)
+	path, ok := fc.Features[0].Geometry.Coordinates.([]interface{})
+	require.True(t, ok)
+	assert.Len(t, path, len(fc.Features), "The path has the origin and one position per stop")
+	assert.Equal(t, []interface{}{-74.0, 40.7}, path[0])
+}
+
+func TestImportLocationsGPX_DefaultColor(t *testing.T) {
+	gpx := `<?xml version="1.0"?>
+<gpx version="1.1" xmlns="http://www.topografix.com/GPX/1/1">
+  <wpt lat="40.5" lon="29.5"><name>GPX Depot</name></wpt>
+  <rte><rtept lat="40.6" lon="29.6"><name>GPX Shop</name></rtept></rte>
+</gpx>`
+	resp := testutils.PostRaw(t, "/api/v1/locations/import", "application/gpx+xml", strings.NewReader(gpx))
+	result := decodeImport(t, resp)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "GPX waypoints have no color")
+	require.Len(t, result.Errors, 2)
+	assert.Contains(t, result.Errors[0].Details, "Color")
+
+	resp = testutils.PostRaw(t, "/api/v1/locations/import?color=%23123456", "application/gpx+xml", strings.NewReader(gpx))
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, 2, decodeImport(t, resp).Created)
+
+	var stored model.Location
+	require.NoError(t, testutils.TestDB.Where("name = ?", "GPX Shop").First(&stored).Error)
+	assert.Equal(t, "#123456", stored.Color)
+}
+
+func TestImportLocationsKML_Multipart(t *testing.T) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	file, err := form.CreateFormFile("file", "pins.kml")
+	require.NoError(t, err)
+	_, err = file.Write([]byte(`<kml xmlns="http://www.opengis.net/kml/2.2"><Document>
+  <Style id="green"><IconStyle><color>ff00ff00</color></IconStyle></Style>
+  <Placemark><name>KML Pin</name><styleUrl>#green</styleUrl><Point><coordinates>29.5,40.5</coordinates></Point></Placemark>
+  <Placemark><name>KML Path</name><LineString><coordinates>29.5,40.5 29.6,40.6</coordinates></LineString></Placemark>
+</Document></kml>`))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	resp := testutils.PostRaw(t, "/api/v1/locations/import", form.FormDataContentType(), &body)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	result := decodeImport(t, resp)
+	assert.Equal(t, 1, result.Rows, "paths are not locations")
+	assert.Equal(t, 1, result.Created)
+
+	var stored model.Location
+	require.NoError(t, testutils.TestDB.Where("name = ?", "KML Pin").First(&stored).Error)
+	assert.Equal(t, "#00ff00", stored.Color)
+}
+
+func TestImportLocations_UnknownFormat(t *testing.T) {
+	resp := testutils.PostRaw(t, "/api/v1/locations/import?format=kmz", "application/octet-stream", strings.NewReader(""))
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestGetRoute_GPX(t *testing.T) {
+	resp := testutils.Get(t, "/api/v1/route?lat=40.7&lng=-74&format=gpx")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/gpx+xml; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="route.gpx"`, resp.Header.Get("Content-Disposition"))
+
+	body := readAndLogBody(t, resp)
+	var gpx struct {
+		Points []struct {
+			Lat  float64 `xml:"lat,attr"`
+			Name string  `xml:"name"`
+		} `xml:"rte>rtept"`
+	}
+	require.NoError(t, xml.Unmarshal(body, &gpx), "Failed to decode GPX")
+	require.NotEmpty(t, gpx.Points)
+	assert.NotEmpty(t, gpx.Points[0].Name)
+}
+
+func TestGetRoute_KML(t *testing.T) {
+	resp := testutils.GetAccept(t, "/api/v1/route?lat=40.7&lng=-74", "application/vnd.google-earth.kml+xml")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/vnd.google-earth.kml+xml; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	body := readAndLogBody(t, resp)
+	var kml struct {
+		Placemarks []struct {
+			StyleURL   string    `xml:"styleUrl"`
+			LineString *struct{} `xml:"LineString"`
+		} `xml:"Document>Placemark"`
+	}
+	require.NoError(t, xml.Unmarshal(body, &kml), "Failed to decode KML")
+	require.NotEmpty(t, kml.Placemarks)
+	assert.NotEmpty(t, kml.Placemarks[0].StyleURL, "stops are styled with their color")
+	assert.NotNil(t, kml.Placemarks[len(kml.Placemarks)-1].LineString, "the path comes last")
+}
+
+func TestGetRoute_InvalidFormat(t *testing.T) {
+	resp := testutils.Get(t, "/api/v1/route?lat=40.7&lng=-74&format=shp")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -1,43 +1,132 @@
 package testutils
 
 import (
+	"context"
 	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 
+	"github.com/gin-gonic/gin"
+	"github.com/yusufbulac/location-routing-service/internal/cache"
 	"github.com/yusufbulac/location-routing-service/internal/config"
+	"github.com/yusufbulac/location-routing-service/internal/migrations"
 	"github.com/yusufbulac/location-routing-service/internal/model"
+	"github.com/yusufbulac/location-routing-service/internal/repository"
+	"github.com/yusufbulac/location-routing-service/internal/server"
 	"gorm.io/gorm"
 )
 
-var TestDB *gorm.DB
+var (
+	TestDB *gorm.DB
+	// Locations writes fixtures through the repository so every write bumps
+	// the data version and the server's spatial index picks it up.
+	Locations repository.LocationRepository
 
+	testConfig *config.Config
+	tempDir    string
+	testServer *httptest.Server
+)
+
+// SetupTestDB connects to the test database and applies pending migrations. Unless SQLITE_PATH is set, the
+// SQLite driver uses a fresh file in a temporary directory.
 func SetupTestDB() {
-	TestDB = config.ConnectTestDatabase()
+	var err error
+	tempDir, err = os.MkdirTemp("", "location-routing-integration-")
+	if err != nil {
+		log.Fatalf("Failed to create temporary directory: %v", err)
+	}
+
+	var args []string
+	if os.Getenv("SQLITE_PATH") == "" {
+		args = append(args, "-sqlite-path", filepath.Join(tempDir, "integration.db"))
+	}
+
+	testConfig, err = config.LoadTestConfig(args...)
+	if err != nil {
+		log.Fatalf("Invalid test configuration:\n%v", err)
+	}
+
+	TestDB, err = config.ConnectDatabase(testConfig.Database)
+	if err != nil {
+		log.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	migrator, err := migrations.New(TestDB)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		log.Fatalf("Failed to migrate test database: %v", err)
+	}
+	Locations = repository.NewLocationRepository(TestDB)
 }
 
-func CleanDatabase() {
+// SetupTestServer serves the application over TestDB on a local port and
+// points the request helpers at it.
+func SetupTestServer() {
 	if TestDB == nil {
 		log.Fatal("TestDB is not initialized. Call SetupTestDB() first.")
 	}
 
-	TestDB.Exec("SET FOREIGN_KEY_CHECKS = 0")
-	TestDB.Exec("DELETE FROM locations")
-	TestDB.Exec("SET FOREIGN_KEY_CHECKS = 1")
+	gin.SetMode(gin.TestMode)
+	router, err := server.NewRouter(context.Background(), testConfig, TestDB, cache.NewMemoryCache(cache.DefaultMemoryCapacity))
+	if err != nil {
+		log.Fatalf("Failed to build test server: %v", err)
+	}
+
+	testServer = httptest.NewServer(router)
+	baseURL = testServer.URL
 }
 
-func SeedLocations() {
+// Teardown stops the test server, closes the database and removes temporary files.
+func Teardown() {
+	if testServer != nil {
+		testServer.Close()
+	}
+	if TestDB != nil {
+		if sqlDB, err := TestDB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+	os.RemoveAll(tempDir)
+}
+
+// CleanDatabase removes every location and bumps the data version so a
+// running server drops its cached index.
+func CleanDatabase() {
 	if TestDB == nil {
 		log.Fatal("TestDB is not initialized. Call SetupTestDB() first.")
 	}
 
+	err := TestDB.Transaction(func(tx *gorm.DB) error {
+		if TestDB.Dialector.Name() == config.DriverMySQL {
+			tx.Exec("SET FOREIGN_KEY_CHECKS = 0")
+			defer tx.Exec("SET FOREIGN_KEY_CHECKS = 1")
+		}
+		if err := tx.Exec("DELETE FROM locations").Error; err != nil {
+			return err
+		}
+		return tx.Exec("UPDATE dataset_version SET version = version + 1 WHERE id = 1").Error
+	})
+	if err != nil {
+		log.Fatalf("failed to clean database: %v", err)
+	}
+}
+
+// SeedLocations stores the fixture locations through the repository.
+func SeedLocations() {
+	if Locations == nil {
+		log.Fatal("Locations is not initialized. Call SetupTestDB() first.")
+	}
+
 	locations := []model.Location{
 		{Name: "Point A", Latitude: 40.7128, Longitude: -74.0060, Color: "#ff0000"},
 		{Name: "Point B", Latitude: 34.0522, Longitude: -118.2437, Color: "#00ff00"},
 		{Name: "Point C", Latitude: 41.8781, Longitude: -87.6298, Color: "#0000ff"},
 	}
 
-	for _, loc := range locations {
-		if err := TestDB.Create(&loc).Error; err != nil {
-			log.Fatalf("failed to seed location: %v", err)
-		}
+	if err := Locations.CreateBatch(context.Background(), locations); err != nil {
+		log.Fatalf("failed to seed locations: %v", err)
 	}
 }
@@ -3,11 +3,13 @@ package testutils
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"net/http"
 	"testing"
 )
 
-const baseURL = "http://localhost:8080"
+// baseURL is the address of the server under test, set by SetupTestServer.
+var baseURL = "http://localhost:8080"
 
 func Get(t *testing.T, path string) *http.Response {
 	resp, err := http.Get(baseURL + path)
@@ -30,6 +32,30 @@ func Post(t *testing.T, path string, body interface{}) *http.Response {
 	return resp
 }
 
+// GetAccept sends a GET request with the given Accept header.
+func GetAccept(t *testing.T, path, accept string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, baseURL+path, nil)
+	if err != nil {
+		t.Fatalf("Failed to create GET request to %s: %v", path, err)
+	}
+	req.Header.Set("Accept", accept)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET request to %s failed: %v", path, err)
+	}
+	return resp
+}
+
+// PostRaw sends body as is with the given content type.
+func PostRaw(t *testing.T, path, contentType string, body io.Reader) *http.Response {
+	resp, err := http.Post(baseURL+path, contentType, body)
+	if err != nil {
+		t.Fatalf("POST request to %s failed: %v", path, err)
+	}
+	return resp
+}
+
 func Put(t *testing.T, path string, body interface{}) *http.Response {
 	data, err := json.Marshal(body)
 	if err != nil {
@@ -49,3 +75,32 @@ func Put(t *testing.T, path string, body interface{}) *http.Response {
 	}
 	return resp
 }
+
+func Delete(t *testing.T, path string) *http.Response {
+	req, err := http.NewRequest(http.MethodDelete, baseURL+path, nil)
+	if err != nil {
+		t.Fatalf("Failed to create DELETE request to %s: %v", path, err)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE request to %s failed: %v", path, err)
+	}
+	return resp
+}
+
+// Admin sends a request to an admin endpoint with the configured admin token.
+func Admin(t *testing.T, method, path string) *http.Response {
+	req, err := http.NewRequest(method, baseURL+path, nil)
+	if err != nil {
+		t.Fatalf("Failed to create %s request to %s: %v", method, path, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testConfig.AdminToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s request to %s failed: %v", method, path, err)
+	}
+	return resp
+}